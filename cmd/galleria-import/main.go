@@ -0,0 +1,161 @@
+// Command galleria-import imports posts in bulk from a CSV or JSON manifest,
+// or a ZIP archive of images along with one, through the galleria API. It
+// waits for the import to finish and prints the result of every row.
+//
+//	galleria-import -token $GALLERIA_TOKEN portfolio.zip
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/database/models"
+)
+
+var contentTypes = map[string]string{
+	"csv":  "text/csv",
+	"json": "application/json",
+	"zip":  "application/zip",
+}
+
+func main() {
+	server := flag.String("server", "http://localhost:8080", "address of the galleria API")
+	token := flag.String("token", os.Getenv("GALLERIA_TOKEN"), "access token, defaults to $GALLERIA_TOKEN")
+	format := flag.String("format", "", "csv, json or zip, defaults to the extension of the manifest")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: galleria-import [flags] <manifest>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 || *token == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	failed, err := run(strings.TrimSuffix(*server, "/"), *token, *format, flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func run(server, token, format, manifest string) (failed int, err error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(manifest)), ".")
+	}
+
+	contentType, ok := contentTypes[format]
+	if !ok {
+		return 0, fmt.Errorf("unsupported format %q, use -format", format)
+	}
+
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		return 0, err
+	}
+
+	client := &client{server: server, token: token}
+
+	var job models.ImportJob
+	if err := client.do(http.MethodPost, "/galleria/imports", contentType, data, &job); err != nil {
+		return 0, err
+	}
+
+	fmt.Printf("import %s queued with %d rows\n", job.ID, job.Total)
+
+	for job.Status == "pending" || job.Status == "running" {
+		time.Sleep(time.Second)
+
+		if err := client.do(http.MethodGet, "/galleria/imports/"+job.ID.String(), "", nil, &job); err != nil {
+			return 0, err
+		}
+
+		fmt.Printf("\r%d/%d rows processed", job.Processed, job.Total)
+	}
+	fmt.Println()
+
+	for _, result := range job.Report {
+		fmt.Println(describe(result))
+	}
+
+	fmt.Printf("%d imported, %d failed\n", job.Succeeded, job.Failed)
+
+	if job.Status == "failed" && job.Error != nil {
+		return job.Failed, errors.New(*job.Error)
+	}
+
+	return job.Failed, nil
+}
+
+func describe(result models.ImportResult) string {
+	switch {
+	case result.ImageID != nil:
+		return fmt.Sprintf("row %d: imported as %s", result.Row, result.ImageID)
+	case len(result.Problems) > 0:
+		fields := make([]string, 0, len(result.Problems))
+		for field := range result.Problems {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		problems := make([]string, len(fields))
+		for i, field := range fields {
+			problems[i] = field + ": " + result.Problems[field]
+		}
+
+		return fmt.Sprintf("row %d: %s", result.Row, strings.Join(problems, "; "))
+	default:
+		return fmt.Sprintf("row %d: %s", result.Row, result.Error)
+	}
+}
+
+type client struct {
+	server string
+	token  string
+}
+
+func (c *client) do(method, path, contentType string, body []byte, v any) error {
+	req, err := http.NewRequest(method, c.server+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var list api.ErrorList
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, &list) == nil && len(list.Errors) > 0 {
+			return fmt.Errorf("%s: %s", resp.Status, list.Errors[0].Message)
+		}
+
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -10,7 +10,17 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/edulustosa/galleria/internal/analytics"
 	"github.com/edulustosa/galleria/internal/api/router"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/exports"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/imports"
+	"github.com/edulustosa/galleria/internal/palette"
+	"github.com/edulustosa/galleria/internal/storage"
+	"github.com/edulustosa/galleria/internal/stream"
+	"github.com/edulustosa/galleria/internal/trash"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
@@ -50,8 +60,43 @@ func run(ctx context.Context) error {
 		return err
 	}
 
+	broker := stream.NewBroker(pool)
+	go broker.Run(ctx)
+
+	publicURL := getenv("PUBLIC_URL", "http://localhost:8080")
+	files := storage.New(getenv("STORAGE_DIR", "storage"), publicURL)
+
+	palettes := palette.NewWorker(repo.NewPGXPalettesRepository(pool), files)
+	go palettes.Run(ctx)
+
+	purger := trash.NewPurger(repo.NewPGXTrashRepository(pool))
+	go purger.Run(ctx)
+
+	scheduler := galleria.NewScheduler(factories.MakeGalleriaService(pool))
+	go scheduler.Run(ctx)
+
+	importer := imports.NewWorker(factories.MakeImportsService(pool, files))
+	go importer.Run(ctx)
+
+	exporter := exports.NewWorker(factories.MakeExportsService(pool, files))
+	go exporter.Run(ctx)
+
+	// The recorder flushes what it counted once stopped, the pool must outlive
+	// it.
+	recorderCtx, stopRecorder := context.WithCancel(ctx)
+	recorderDone := make(chan struct{})
+	recorder := analytics.NewRecorder(repo.NewPGXAnalyticsRepository(pool))
+	go func() {
+		recorder.Run(recorderCtx)
+		close(recorderDone)
+	}()
+	defer func() {
+		stopRecorder()
+		<-recorderDone
+	}()
+
 	jwtKey := os.Getenv("JWT_SECRET")
-	srv := router.NewServer(pool, jwtKey)
+	srv := router.NewServer(pool, jwtKey, broker, recorder, files, publicURL)
 	httpServer := &http.Server{
 		Addr:         ":8080",
 		Handler:      srv,
@@ -79,6 +124,14 @@ func run(ctx context.Context) error {
 	return nil
 }
 
+func getenv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
 func shutdown(srv *http.Server) {
 	const timeout = 30 * time.Second
 
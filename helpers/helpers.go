@@ -2,7 +2,11 @@ package helpers
 
 import (
 	"errors"
+	"net"
+	"net/http"
 	"net/url"
+	"syscall"
+	"time"
 )
 
 func ValidateURL(urlToValidate string) error {
@@ -17,3 +21,42 @@ func ValidateURL(urlToValidate string) error {
 
 	return nil
 }
+
+// Periods maps the names of the periods rankings can be restricted to, to
+// their duration. All is zero, meaning no restriction.
+var Periods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"all":   0,
+}
+
+var errPrivateAddress = errors.New("refusing to connect to a non public address")
+
+// publicOnly refuses connections to loopback, private and link local
+// addresses, image urls are chosen by users and must not reach internal
+// services.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return errPrivateAddress
+	}
+
+	return nil
+}
+
+// PublicClient returns a client for urls chosen by users, which only
+// connects to public addresses and ignores proxies.
+func PublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: publicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
@@ -0,0 +1,294 @@
+package albums
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+type Albums struct {
+	albumsRepository repo.AlbumsRepository
+	imagesRepository repo.ImagesRepository
+	usersRepository  repo.UsersRepository
+	blocksRepository repo.BlocksRepository
+}
+
+func New(
+	albumsRepository repo.AlbumsRepository,
+	imagesRepository repo.ImagesRepository,
+	usersRepository repo.UsersRepository,
+	blocksRepository repo.BlocksRepository,
+) *Albums {
+	return &Albums{
+		albumsRepository: albumsRepository,
+		imagesRepository: imagesRepository,
+		usersRepository:  usersRepository,
+		blocksRepository: blocksRepository,
+	}
+}
+
+var (
+	ErrAlbumNotFound   = errors.New("album not found")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrImageNotFound   = errors.New("image not found")
+	ErrNotAlbumOwner   = errors.New("album belongs to another user")
+	ErrImagesMismatch  = errors.New("image ids must be exactly the visible images of the album")
+	ErrCoverNotInAlbum = errors.New("cover image must be in the album")
+)
+
+type CreateAlbumRequest struct {
+	Title       string  `json:"title"`
+	Description *string `json:"description"`
+}
+
+func (r CreateAlbumRequest) Valid() (problems map[string]string) {
+	problems = make(map[string]string)
+
+	if r.Title == "" || len(r.Title) > 255 {
+		problems["title"] = "title must be between 1 and 255 characters"
+	}
+
+	if r.Description != nil && len(*r.Description) > 500 {
+		problems["description"] = "description must be less than 500 characters"
+	}
+
+	return problems
+}
+
+type UpdateAlbumRequest struct {
+	Title        *string    `json:"title"`
+	Description  *string    `json:"description"`
+	CoverImageID *uuid.UUID `json:"coverImageId"`
+}
+
+func (r UpdateAlbumRequest) Valid() (problems map[string]string) {
+	problems = make(map[string]string)
+
+	if r.Title != nil && (*r.Title == "" || len(*r.Title) > 255) {
+		problems["title"] = "title must be between 1 and 255 characters"
+	}
+
+	if r.Description != nil && len(*r.Description) > 500 {
+		problems["description"] = "description must be less than 500 characters"
+	}
+
+	return problems
+}
+
+type ReorderRequest struct {
+	ImageIDs []uuid.UUID `json:"imageIds"`
+}
+
+func (r ReorderRequest) Valid() (problems map[string]string) {
+	problems = make(map[string]string)
+
+	if len(r.ImageIDs) == 0 {
+		problems["imageIds"] = "image ids must not be empty"
+	}
+
+	return problems
+}
+
+func (a *Albums) Create(
+	ctx context.Context,
+	userID uuid.UUID,
+	req *CreateAlbumRequest,
+) (uuid.UUID, error) {
+	album := &models.Album{
+		UserID:      userID,
+		Title:       req.Title,
+		Description: req.Description,
+	}
+
+	return a.albumsRepository.Create(ctx, album)
+}
+
+// Get returns the album with its images in order, leaving out the images
+// viewerID cannot see. Users the album owner blocked get ErrAlbumNotFound.
+func (a *Albums) Get(
+	ctx context.Context,
+	viewerID, albumID uuid.UUID,
+) (*models.Album, []models.Post, error) {
+	album, err := a.albumsRepository.FindByID(ctx, albumID, viewerID)
+	if err != nil {
+		return nil, nil, ErrAlbumNotFound
+	}
+
+	blocked, err := a.blockedBy(ctx, album.UserID, viewerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if blocked {
+		return nil, nil, ErrAlbumNotFound
+	}
+
+	images, err := a.albumsRepository.FindImages(ctx, albumID, viewerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return album, images, nil
+}
+
+func (a *Albums) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.Album, error) {
+	return a.albumsRepository.FindByUserID(ctx, userID, userID)
+}
+
+// ListByUsername returns the albums shown on the profile of the user with
+// the username. viewerID is uuid.Nil for anonymous visitors, users the
+// account blocked get ErrUserNotFound.
+func (a *Albums) ListByUsername(
+	ctx context.Context,
+	viewerID uuid.UUID,
+	username string,
+) ([]models.Album, error) {
+	user, err := a.usersRepository.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	blocked, err := a.blockedBy(ctx, user.ID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if blocked {
+		return nil, ErrUserNotFound
+	}
+
+	return a.albumsRepository.FindByUserID(ctx, user.ID, viewerID)
+}
+
+// blockedBy reports whether ownerID blocked viewerID. Anonymous visitors and
+// the owner are never blocked.
+func (a *Albums) blockedBy(ctx context.Context, ownerID, viewerID uuid.UUID) (bool, error) {
+	if viewerID == uuid.Nil || viewerID == ownerID {
+		return false, nil
+	}
+
+	return a.blocksRepository.IsBlocked(ctx, ownerID, viewerID)
+}
+
+// owned returns the album if it belongs to userID.
+func (a *Albums) owned(ctx context.Context, userID, albumID uuid.UUID) (*models.Album, error) {
+	album, err := a.albumsRepository.FindByID(ctx, albumID, userID)
+	if err != nil {
+		return nil, ErrAlbumNotFound
+	}
+
+	if album.UserID != userID {
+		return nil, ErrNotAlbumOwner
+	}
+
+	return album, nil
+}
+
+func (a *Albums) Update(
+	ctx context.Context,
+	userID, albumID uuid.UUID,
+	req *UpdateAlbumRequest,
+) error {
+	album, err := a.owned(ctx, userID, albumID)
+	if err != nil {
+		return err
+	}
+
+	if req.Title != nil {
+		album.Title = *req.Title
+	}
+
+	if req.Description != nil {
+		album.Description = req.Description
+	}
+
+	if req.CoverImageID != nil {
+		images, err := a.albumsRepository.FindImages(ctx, albumID, userID)
+		if err != nil {
+			return err
+		}
+
+		if !containsImage(images, *req.CoverImageID) {
+			return ErrCoverNotInAlbum
+		}
+		album.CoverImageID = req.CoverImageID
+	}
+
+	return a.albumsRepository.Update(ctx, album)
+}
+
+func containsImage(posts []models.Post, imageID uuid.UUID) bool {
+	for _, post := range posts {
+		if post.Image.ID == imageID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *Albums) Delete(ctx context.Context, userID, albumID uuid.UUID) error {
+	if _, err := a.owned(ctx, userID, albumID); err != nil {
+		return err
+	}
+
+	return a.albumsRepository.Delete(ctx, albumID)
+}
+
+// AddImage appends the image to the album. Besides their own images, users
+// can add the listed images of other users to curate collections of their
+// work.
+func (a *Albums) AddImage(ctx context.Context, userID, albumID, imageID uuid.UUID) error {
+	if _, err := a.owned(ctx, userID, albumID); err != nil {
+		return err
+	}
+
+	image, err := a.imagesRepository.FindByID(ctx, imageID, userID)
+	if err != nil {
+		return ErrImageNotFound
+	}
+
+	if image.UserID != userID {
+		if _, err := a.imagesRepository.FindListed(ctx, imageID); err != nil {
+			return ErrImageNotFound
+		}
+	}
+
+	return a.albumsRepository.AddImage(ctx, albumID, imageID)
+}
+
+func (a *Albums) RemoveImage(ctx context.Context, userID, albumID, imageID uuid.UUID) error {
+	album, err := a.owned(ctx, userID, albumID)
+	if err != nil {
+		return err
+	}
+
+	if album.CoverImageID != nil && *album.CoverImageID == imageID {
+		album.CoverImageID = nil
+		if err := a.albumsRepository.Update(ctx, album); err != nil {
+			return err
+		}
+	}
+
+	return a.albumsRepository.RemoveImage(ctx, albumID, imageID)
+}
+
+func (a *Albums) Reorder(
+	ctx context.Context,
+	userID, albumID uuid.UUID,
+	req *ReorderRequest,
+) error {
+	if _, err := a.owned(ctx, userID, albumID); err != nil {
+		return err
+	}
+
+	err := a.albumsRepository.Reorder(ctx, albumID, userID, req.ImageIDs)
+	if errors.Is(err, repo.ErrAlbumImagesMismatch) {
+		return ErrImagesMismatch
+	}
+
+	return err
+}
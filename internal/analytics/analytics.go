@@ -0,0 +1,43 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/edulustosa/galleria/helpers"
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+type Analytics struct {
+	analyticsRepository repo.AnalyticsRepository
+}
+
+func New(analyticsRepository repo.AnalyticsRepository) *Analytics {
+	return &Analytics{analyticsRepository}
+}
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+// Report returns the engagement of every post of the user over the period,
+// one of day, week, month or all. It is as fresh as the last flush of the
+// recorder.
+func (a *Analytics) Report(
+	ctx context.Context,
+	userID uuid.UUID,
+	period string,
+) ([]models.ImageAnalytics, error) {
+	within, ok := helpers.Periods[period]
+	if !ok {
+		return nil, ErrInvalidPeriod
+	}
+
+	var since time.Time
+	if within > 0 {
+		since = time.Now().UTC().Add(-within).Truncate(24 * time.Hour)
+	}
+
+	return a.analyticsRepository.FindByUserID(ctx, userID, since)
+}
@@ -0,0 +1,164 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+type Kind int
+
+const (
+	// View is a post opened on its own page.
+	View Kind = iota
+	// Impression is a post shown in a feed.
+	Impression
+)
+
+const (
+	// dedupWindow is how long further views of a post by the same viewer
+	// are not counted again.
+	dedupWindow = 30 * time.Minute
+	// flushInterval is how often counted views are written to the rollups
+	// and likes and comments recounted.
+	flushInterval = time.Minute
+	// flushTimeout bounds the last flush when the recorder stops.
+	flushTimeout = 10 * time.Second
+)
+
+type seenKey struct {
+	kind    Kind
+	imageID uuid.UUID
+	viewer  string
+}
+
+type dayKey struct {
+	imageID uuid.UUID
+	day     time.Time
+}
+
+// Recorder counts views and impressions in memory and flushes them to the
+// daily rollups in batches, so that reading posts never writes to the
+// database. Deduplication only spans this process, and counts not flushed yet
+// are lost if it crashes.
+type Recorder struct {
+	analyticsRepository repo.AnalyticsRepository
+
+	mu      sync.Mutex
+	seen    map[seenKey]time.Time
+	pending map[dayKey]*repo.ViewCount
+}
+
+func NewRecorder(analyticsRepository repo.AnalyticsRepository) *Recorder {
+	return &Recorder{
+		analyticsRepository: analyticsRepository,
+		seen:                make(map[seenKey]time.Time),
+		pending:             make(map[dayKey]*repo.ViewCount),
+	}
+}
+
+// Record counts the posts as seen by viewer, which identifies a signed in
+// user or the address of an anonymous visitor. Posts the viewer was already
+// counted for in the last 30 minutes are skipped.
+func (r *Recorder) Record(kind Kind, viewer string, imageIDs ...uuid.UUID) {
+	now := time.Now().UTC()
+	today := now.Truncate(24 * time.Hour)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, imageID := range imageIDs {
+		key := seenKey{kind, imageID, viewer}
+		if last, ok := r.seen[key]; ok && now.Sub(last) < dedupWindow {
+			continue
+		}
+		r.seen[key] = now
+
+		day := dayKey{imageID, today}
+		count, ok := r.pending[day]
+		if !ok {
+			count = &repo.ViewCount{ImageID: imageID, Day: today}
+			r.pending[day] = count
+		}
+
+		switch kind {
+		case View:
+			count.Views++
+		case Impression:
+			count.Impressions++
+		}
+	}
+}
+
+// Flush writes the pending counts to the rollups and recounts the likes and
+// comments of today and yesterday, yesterday being still open for a while
+// after midnight. Counts that fail to be written are kept for the next flush.
+func (r *Recorder) Flush(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	r.mu.Lock()
+	counts := make([]repo.ViewCount, 0, len(r.pending))
+	for _, count := range r.pending {
+		counts = append(counts, *count)
+	}
+	r.pending = make(map[dayKey]*repo.ViewCount)
+
+	for key, last := range r.seen {
+		if now.Sub(last) >= dedupWindow {
+			delete(r.seen, key)
+		}
+	}
+	r.mu.Unlock()
+
+	if err := r.analyticsRepository.AddViews(ctx, counts); err != nil {
+		r.restore(counts)
+		return err
+	}
+
+	yesterday := now.Truncate(24*time.Hour).AddDate(0, 0, -1)
+	return r.analyticsRepository.RollUp(ctx, yesterday)
+}
+
+func (r *Recorder) restore(counts []repo.ViewCount) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, count := range counts {
+		day := dayKey{count.ImageID, count.Day}
+		pending, ok := r.pending[day]
+		if !ok {
+			pending = &repo.ViewCount{ImageID: count.ImageID, Day: count.Day}
+			r.pending[day] = pending
+		}
+
+		pending.Views += count.Views
+		pending.Impressions += count.Impressions
+	}
+}
+
+// Run flushes every minute until ctx is done, then flushes one last time.
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			defer cancel()
+
+			if err := r.Flush(ctx); err != nil {
+				log.Printf("failed to flush analytics: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := r.Flush(ctx); err != nil {
+				log.Printf("failed to flush analytics: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/edulustosa/galleria/internal/albums"
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func handleAlbumError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, albums.ErrAlbumNotFound),
+		errors.Is(err, albums.ErrImageNotFound),
+		errors.Is(err, albums.ErrUserNotFound):
+		api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+	case errors.Is(err, albums.ErrNotAlbumOwner):
+		api.HandleError(w, http.StatusForbidden, api.Error{Message: err.Error()})
+	case errors.Is(err, albums.ErrImagesMismatch), errors.Is(err, albums.ErrCoverNotInAlbum):
+		api.HandleError(w, http.StatusBadRequest, api.Error{Message: err.Error()})
+	default:
+		log.Printf("failed to handle album: %v", err)
+		api.HandleError(
+			w,
+			http.StatusInternalServerError,
+			api.Error{Message: "something went wrong, please try again"},
+		)
+	}
+}
+
+func HandleCreateAlbum(pool *pgxpool.Pool) http.HandlerFunc {
+	albumsService := factories.MakeAlbumsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		req, problems, err := api.DecodeValid[albums.CreateAlbumRequest](r)
+		if err != nil {
+			api.HandleInvalidRequest(w, problems)
+			return
+		}
+
+		albumID, err := albumsService.Create(r.Context(), userID, &req)
+		if err != nil {
+			handleAlbumError(w, err)
+			return
+		}
+
+		if err := api.Encode(w, http.StatusCreated, api.JSON{"albumId": albumID}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func HandleGetAlbum(pool *pgxpool.Pool) http.HandlerFunc {
+	albumsService := factories.MakeAlbumsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		albumID, ok := uuidParam(w, r, "albumId", "album id")
+		if !ok {
+			return
+		}
+
+		album, images, err := albumsService.Get(r.Context(), viewerID(r), albumID)
+		if err != nil {
+			handleAlbumError(w, err)
+			return
+		}
+
+		resp := api.JSON{"album": album, "images": images}
+		if err = api.Encode(w, http.StatusOK, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func HandleUpdateAlbum(pool *pgxpool.Pool) http.HandlerFunc {
+	albumsService := factories.MakeAlbumsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		albumID, ok := uuidParam(w, r, "albumId", "album id")
+		if !ok {
+			return
+		}
+
+		req, problems, err := api.DecodeValid[albums.UpdateAlbumRequest](r)
+		if err != nil {
+			api.HandleInvalidRequest(w, problems)
+			return
+		}
+
+		if err := albumsService.Update(r.Context(), userID, albumID, &req); err != nil {
+			handleAlbumError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleDeleteAlbum(pool *pgxpool.Pool) http.HandlerFunc {
+	albumsService := factories.MakeAlbumsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		albumID, ok := uuidParam(w, r, "albumId", "album id")
+		if !ok {
+			return
+		}
+
+		if err := albumsService.Delete(r.Context(), userID, albumID); err != nil {
+			handleAlbumError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleAddAlbumImage(pool *pgxpool.Pool) http.HandlerFunc {
+	albumsService := factories.MakeAlbumsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		albumID, ok := uuidParam(w, r, "albumId", "album id")
+		if !ok {
+			return
+		}
+
+		imageID, ok := uuidParam(w, r, "imageId", "image id")
+		if !ok {
+			return
+		}
+
+		if err := albumsService.AddImage(r.Context(), userID, albumID, imageID); err != nil {
+			handleAlbumError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleRemoveAlbumImage(pool *pgxpool.Pool) http.HandlerFunc {
+	albumsService := factories.MakeAlbumsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		albumID, ok := uuidParam(w, r, "albumId", "album id")
+		if !ok {
+			return
+		}
+
+		imageID, ok := uuidParam(w, r, "imageId", "image id")
+		if !ok {
+			return
+		}
+
+		if err := albumsService.RemoveImage(r.Context(), userID, albumID, imageID); err != nil {
+			handleAlbumError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleReorderAlbum(pool *pgxpool.Pool) http.HandlerFunc {
+	albumsService := factories.MakeAlbumsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		albumID, ok := uuidParam(w, r, "albumId", "album id")
+		if !ok {
+			return
+		}
+
+		req, problems, err := api.DecodeValid[albums.ReorderRequest](r)
+		if err != nil {
+			api.HandleInvalidRequest(w, problems)
+			return
+		}
+
+		if err := albumsService.Reorder(r.Context(), userID, albumID, &req); err != nil {
+			handleAlbumError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleGetUserAlbums(pool *pgxpool.Pool) http.HandlerFunc {
+	albumsService := factories.MakeAlbumsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		userAlbums, err := albumsService.ListByUser(r.Context(), userID)
+		if err != nil {
+			handleAlbumError(w, err)
+			return
+		}
+
+		if err = api.Encode(w, http.StatusOK, api.JSON{"albums": userAlbums}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func HandlePublicAlbums(pool *pgxpool.Pool) http.HandlerFunc {
+	albumsService := factories.MakeAlbumsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userAlbums, err := albumsService.ListByUsername(
+			r.Context(),
+			viewerID(r),
+			chi.URLParam(r, "username"),
+		)
+		if err != nil {
+			handleAlbumError(w, err)
+			return
+		}
+
+		if err = api.Encode(w, http.StatusOK, api.JSON{"albums": userAlbums}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/edulustosa/galleria/internal/analytics"
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// viewerKey identifies who is viewing for deduplication, the signed in user
+// or else the address of the client.
+func viewerKey(r *http.Request) string {
+	if userID := viewerID(r); userID != uuid.Nil {
+		return "user:" + userID.String()
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// RealIP replaces the address with the forwarded one, which has no port.
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// recordImpressions counts the posts shown in a feed, owners seeing their own
+// posts are left out.
+func recordImpressions(recorder *analytics.Recorder, r *http.Request, posts []models.Post) {
+	viewer := viewerID(r)
+
+	imageIDs := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		if post.Image.UserID != viewer {
+			imageIDs = append(imageIDs, post.Image.ID)
+		}
+	}
+
+	recorder.Record(analytics.Impression, viewerKey(r), imageIDs...)
+}
+
+func HandleGetPost(pool *pgxpool.Pool, recorder *analytics.Recorder) http.HandlerFunc {
+	galleriaService := factories.MakeGalleriaService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		postID, ok := uuidParam(w, r, "postId", "post id")
+		if !ok {
+			return
+		}
+
+		image, err := galleriaService.GetImage(r.Context(), viewerID(r), postID)
+		if err != nil {
+			if errors.Is(err, galleria.ErrImageNotFound) {
+				api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+				return
+			}
+
+			log.Printf("failed to get post: %v", err)
+			api.HandleError(
+				w,
+				http.StatusInternalServerError,
+				api.Error{Message: "something went wrong, please try again"},
+			)
+			return
+		}
+
+		if image.UserID != viewerID(r) {
+			recorder.Record(analytics.View, viewerKey(r), image.ID)
+		}
+
+		if err = api.Encode(w, http.StatusOK, image); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func HandleAnalytics(pool *pgxpool.Pool) http.HandlerFunc {
+	analyticsService := factories.MakeAnalyticsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		period := r.URL.Query().Get("period")
+		if period == "" {
+			period = "month"
+		}
+
+		posts, err := analyticsService.Report(r.Context(), userID, period)
+		if err != nil {
+			if errors.Is(err, analytics.ErrInvalidPeriod) {
+				api.HandleError(w, http.StatusBadRequest, api.Error{
+					Message: err.Error(),
+					Details: "period must be one of day, week, month or all",
+				})
+				return
+			}
+
+			log.Printf("failed to get analytics: %v", err)
+			api.HandleError(
+				w,
+				http.StatusInternalServerError,
+				api.Error{Message: "something went wrong, please try again"},
+			)
+			return
+		}
+
+		if err = api.Encode(w, http.StatusOK, api.JSON{"posts": posts}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/blocks"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func handleBlockError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, blocks.ErrUserNotFound):
+		api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+	case errors.Is(err, blocks.ErrCannotBlockSelf):
+		api.HandleError(w, http.StatusBadRequest, api.Error{Message: err.Error()})
+	default:
+		log.Printf("failed to handle block: %v", err)
+		api.HandleError(
+			w,
+			http.StatusInternalServerError,
+			api.Error{Message: "something went wrong, please try again"},
+		)
+	}
+}
+
+type blockAction func(b *blocks.Blocks, ctx context.Context, userID, targetID uuid.UUID) error
+
+func handleBlockAction(pool *pgxpool.Pool, action blockAction) http.HandlerFunc {
+	blocksService := factories.MakeBlocksService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		targetID, ok := uuidParam(w, r, "userId", "user id")
+		if !ok {
+			return
+		}
+
+		if err := action(blocksService, r.Context(), userID, targetID); err != nil {
+			handleBlockError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleBlock(pool *pgxpool.Pool) http.HandlerFunc {
+	return handleBlockAction(pool, (*blocks.Blocks).Block)
+}
+
+func HandleUnblock(pool *pgxpool.Pool) http.HandlerFunc {
+	return handleBlockAction(pool, (*blocks.Blocks).Unblock)
+}
+
+func HandleMute(pool *pgxpool.Pool) http.HandlerFunc {
+	return handleBlockAction(pool, (*blocks.Blocks).Mute)
+}
+
+func HandleUnmute(pool *pgxpool.Pool) http.HandlerFunc {
+	return handleBlockAction(pool, (*blocks.Blocks).Unmute)
+}
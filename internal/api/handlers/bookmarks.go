@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/bookmarks"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func handleBookmarkError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bookmarks.ErrImageNotFound):
+		api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+	case errors.Is(err, bookmarks.ErrInvalidCursor):
+		api.HandleError(w, http.StatusBadRequest, api.Error{
+			Message: err.Error(),
+			Details: "cursor must be a nextCursor returned by a previous request",
+		})
+	default:
+		log.Printf("failed to handle bookmark: %v", err)
+		api.HandleError(
+			w,
+			http.StatusInternalServerError,
+			api.Error{Message: "something went wrong, please try again"},
+		)
+	}
+}
+
+func HandleAddBookmark(pool *pgxpool.Pool) http.HandlerFunc {
+	bookmarksService := factories.MakeBookmarksService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		postID, ok := uuidParam(w, r, "postId", "post id")
+		if !ok {
+			return
+		}
+
+		if err := bookmarksService.Save(r.Context(), userID, postID); err != nil {
+			handleBookmarkError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleRemoveBookmark(pool *pgxpool.Pool) http.HandlerFunc {
+	bookmarksService := factories.MakeBookmarksService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		postID, ok := uuidParam(w, r, "postId", "post id")
+		if !ok {
+			return
+		}
+
+		if err := bookmarksService.Unsave(r.Context(), userID, postID); err != nil {
+			handleBookmarkError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleGetBookmarks(pool *pgxpool.Pool) http.HandlerFunc {
+	bookmarksService := factories.MakeBookmarksService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		posts, nextCursor, err := bookmarksService.List(r.Context(), userID, r.URL.Query().Get("cursor"))
+		if err != nil {
+			handleBookmarkError(w, err)
+			return
+		}
+
+		resp := api.JSON{
+			"posts":      posts,
+			"nextCursor": nullableCursor(nextCursor),
+		}
+		if err = api.Encode(w, http.StatusOK, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/exports"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// exportWriteTimeout replaces the write timeout of the server for archives,
+// which take longer than a few seconds to send.
+const exportWriteTimeout = 30 * time.Minute
+
+const exportFilename = "galleria-export.zip"
+
+func handleExportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, exports.ErrExportNotFound):
+		api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+	case errors.Is(err, exports.ErrExportNotReady):
+		api.HandleError(w, http.StatusConflict, api.Error{
+			Message: err.Error(),
+			Details: "wait for the status of the export to be completed",
+		})
+	default:
+		log.Printf("failed to handle export: %v", err)
+		api.HandleError(
+			w,
+			http.StatusInternalServerError,
+			api.Error{Message: "something went wrong, please try again"},
+		)
+	}
+}
+
+func extendWriteDeadline(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil {
+		log.Printf("failed to extend write deadline: %v", err)
+	}
+}
+
+// HandleExportImages streams the archive of the gallery of the user when it
+// is small enough. Larger galleries are exported in the background, the job
+// is returned and tells where to download the archive once completed.
+func HandleExportImages(pool *pgxpool.Pool, files *storage.Disk) http.HandlerFunc {
+	exportsService := factories.MakeExportsService(pool, files)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		images, err := exportsService.Images(r.Context(), userID)
+		if err != nil {
+			handleExportError(w, err)
+			return
+		}
+
+		if len(images) > exports.StreamLimit {
+			job, err := exportsService.Start(r.Context(), userID, len(images))
+			if err != nil {
+				handleExportError(w, err)
+				return
+			}
+
+			w.Header().Set("Location", "/profile/exports/"+job.ID.String())
+			if err = api.Encode(w, http.StatusAccepted, job); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		extendWriteDeadline(w)
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+exportFilename+`"`)
+
+		// The status is already sent, a failure can only cut the archive short.
+		if err := exportsService.Write(r.Context(), w, images, nil); err != nil && r.Context().Err() == nil {
+			log.Printf("failed to stream export: %v", err)
+		}
+	}
+}
+
+func HandleGetExport(pool *pgxpool.Pool, files *storage.Disk) http.HandlerFunc {
+	exportsService := factories.MakeExportsService(pool, files)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		exportID, ok := uuidParam(w, r, "exportId", "export id")
+		if !ok {
+			return
+		}
+
+		job, err := exportsService.Get(r.Context(), userID, exportID)
+		if err != nil {
+			handleExportError(w, err)
+			return
+		}
+
+		if err = api.Encode(w, http.StatusOK, job); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func HandleDownloadExport(pool *pgxpool.Pool, files *storage.Disk) http.HandlerFunc {
+	exportsService := factories.MakeExportsService(pool, files)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		exportID, ok := uuidParam(w, r, "exportId", "export id")
+		if !ok {
+			return
+		}
+
+		f, err := exportsService.Open(r.Context(), userID, exportID)
+		if err != nil {
+			handleExportError(w, err)
+			return
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			handleExportError(w, err)
+			return
+		}
+
+		extendWriteDeadline(w)
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+exportFilename+`"`)
+		http.ServeContent(w, r, exportFilename, info.ModTime(), f)
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/feeds"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// feedMaxAge is how long readers may cache feeds, in seconds, before asking
+// again with their validators.
+const feedMaxAge = "300"
+
+// feedFormat renders posts as a feed of its content type.
+type feedFormat struct {
+	contentType string
+	render      func(feeds.Channel, []models.Post) ([]byte, error)
+}
+
+var (
+	rssFeed  = feedFormat{"application/rss+xml; charset=utf-8", feeds.RSS}
+	atomFeed = feedFormat{"application/atom+xml; charset=utf-8", feeds.Atom}
+)
+
+// handleFeed serves the newest posts matching the query as a feed, through
+// the same Browse query as HandleGalleria. Feeds are public: they are always
+// built as seen by someone signed out. Readers revalidate them with
+// If-None-Match or If-Modified-Since and get a 304 when nothing changed.
+func handleFeed(pool *pgxpool.Pool, publicURL string, format feedFormat, byUser bool) http.HandlerFunc {
+	galleriaService := factories.MakeGalleriaService(pool)
+	publicURL = strings.TrimSuffix(publicURL, "/")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, ok := browseOptions(w, r)
+		if !ok {
+			return
+		}
+		opts.Sort = ""
+		opts.ViewerID = uuid.Nil
+
+		channel := feeds.Channel{
+			Title:       "Galleria",
+			Description: "The newest posts on Galleria",
+			Link:        publicURL + "/galleria",
+			Self:        publicURL + r.URL.RequestURI(),
+			PostURL: func(post *models.Post) string {
+				return publicURL + "/galleria/posts/" + post.Image.ID.String()
+			},
+		}
+
+		if byUser {
+			opts.Username = chi.URLParam(r, "username")
+			channel.Title = opts.Username + " on Galleria"
+			channel.Description = "The newest posts of " + opts.Username + " on Galleria"
+			channel.Link = publicURL + "/users/" + url.PathEscape(opts.Username)
+		}
+
+		if problems := opts.Valid(); len(problems) > 0 {
+			api.HandleInvalidRequest(w, problems)
+			return
+		}
+
+		posts, _, err := galleriaService.Browse(r.Context(), opts)
+		if err != nil {
+			switch {
+			case errors.Is(err, galleria.ErrUserNotFound):
+				api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+			case errors.Is(err, galleria.ErrInvalidCursor):
+				api.HandleError(w, http.StatusBadRequest, api.Error{Message: err.Error()})
+			default:
+				log.Printf("failed to get feed: %v", err)
+				api.HandleError(
+					w,
+					http.StatusInternalServerError,
+					api.Error{Message: "something went wrong, please try again"},
+				)
+			}
+			return
+		}
+
+		body, err := format.render(channel, posts)
+		if err != nil {
+			log.Printf("failed to render feed: %v", err)
+			api.HandleError(
+				w,
+				http.StatusInternalServerError,
+				api.Error{Message: "something went wrong, please try again"},
+			)
+			return
+		}
+
+		// The ETag changes with anything in the feed, deletions included,
+		// which the last modified time alone would miss.
+		sum := sha256.Sum256(body)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Cache-Control", "public, max-age="+feedMaxAge)
+
+		http.ServeContent(w, r, "", feeds.Updated(posts), bytes.NewReader(body))
+	}
+}
+
+func HandleGalleriaRSS(pool *pgxpool.Pool, publicURL string) http.HandlerFunc {
+	return handleFeed(pool, publicURL, rssFeed, false)
+}
+
+func HandleGalleriaAtom(pool *pgxpool.Pool, publicURL string) http.HandlerFunc {
+	return handleFeed(pool, publicURL, atomFeed, false)
+}
+
+func HandleUserAtom(pool *pgxpool.Pool, publicURL string) http.HandlerFunc {
+	return handleFeed(pool, publicURL, atomFeed, true)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/edulustosa/galleria/internal/analytics"
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/follows"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func handleFollowError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, follows.ErrUserNotFound):
+		api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+	case errors.Is(err, follows.ErrCannotFollowSelf):
+		api.HandleError(w, http.StatusBadRequest, api.Error{Message: err.Error()})
+	case errors.Is(err, follows.ErrBlocked):
+		api.HandleError(w, http.StatusForbidden, api.Error{Message: err.Error()})
+	case errors.Is(err, follows.ErrInvalidCursor):
+		api.HandleError(w, http.StatusBadRequest, api.Error{
+			Message: err.Error(),
+			Details: "cursor must be a nextCursor returned by a previous request",
+		})
+	default:
+		log.Printf("failed to handle follow: %v", err)
+		api.HandleError(
+			w,
+			http.StatusInternalServerError,
+			api.Error{Message: "something went wrong, please try again"},
+		)
+	}
+}
+
+func HandleFollow(pool *pgxpool.Pool) http.HandlerFunc {
+	followsService := factories.MakeFollowsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		followerID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		followeeID, ok := uuidParam(w, r, "userId", "user id")
+		if !ok {
+			return
+		}
+
+		if err := followsService.Follow(r.Context(), followerID, followeeID); err != nil {
+			handleFollowError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleUnfollow(pool *pgxpool.Pool) http.HandlerFunc {
+	followsService := factories.MakeFollowsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		followerID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		followeeID, ok := uuidParam(w, r, "userId", "user id")
+		if !ok {
+			return
+		}
+
+		if err := followsService.Unfollow(r.Context(), followerID, followeeID); err != nil {
+			handleFollowError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleFollowers(pool *pgxpool.Pool) http.HandlerFunc {
+	followsService := factories.MakeFollowsService(pool)
+	return handleFollowList("followers", followsService.Followers)
+}
+
+func HandleFollowing(pool *pgxpool.Pool) http.HandlerFunc {
+	followsService := factories.MakeFollowsService(pool)
+	return handleFollowList("following", followsService.Following)
+}
+
+func handleFollowList(
+	key string,
+	list func(ctx context.Context, userID uuid.UUID, cursor string) (*follows.FollowList, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := uuidParam(w, r, "userId", "user id")
+		if !ok {
+			return
+		}
+
+		result, err := list(r.Context(), userID, r.URL.Query().Get("cursor"))
+		if err != nil {
+			handleFollowError(w, err)
+			return
+		}
+
+		resp := api.JSON{
+			key:          result.Users,
+			"counts":     result.Counts,
+			"nextCursor": nullableCursor(result.NextCursor),
+		}
+		if err = api.Encode(w, http.StatusOK, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func HandleHomeFeed(pool *pgxpool.Pool, recorder *analytics.Recorder) http.HandlerFunc {
+	followsService := factories.MakeFollowsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		posts, nextCursor, err := followsService.Feed(r.Context(), userID, r.URL.Query().Get("cursor"))
+		if err != nil {
+			handleFollowError(w, err)
+			return
+		}
+		recordImpressions(recorder, r, posts)
+
+		resp := api.JSON{
+			"posts":      posts,
+			"nextCursor": nullableCursor(nextCursor),
+		}
+		if err = api.Encode(w, http.StatusOK, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
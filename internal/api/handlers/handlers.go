@@ -5,13 +5,16 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/edulustosa/galleria/internal/analytics"
 	"github.com/edulustosa/galleria/internal/api"
 	"github.com/edulustosa/galleria/internal/auth"
 	"github.com/edulustosa/galleria/internal/database/repo"
 	"github.com/edulustosa/galleria/internal/factories"
 	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/licenses"
 	"github.com/edulustosa/galleria/internal/profile"
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
@@ -36,7 +39,7 @@ func HandleRegister(pool *pgxpool.Pool) http.HandlerFunc {
 
 		userId, err := authService.Register(r.Context(), &req)
 		if err != nil {
-			if errors.Is(err, auth.ErrUserAlreadyExists) {
+			if errors.Is(err, auth.ErrUserAlreadyExists) || errors.Is(err, auth.ErrUsernameTaken) {
 				api.HandleError(w, http.StatusConflict, api.Error{Message: err.Error()})
 				return
 			}
@@ -167,6 +170,11 @@ func HandleUpdateProfile(pool *pgxpool.Pool) http.HandlerFunc {
 
 		err = profileService.Update(r.Context(), userId, &req)
 		if err != nil {
+			if errors.Is(err, profile.ErrUsernameTaken) {
+				api.HandleError(w, http.StatusConflict, api.Error{Message: err.Error()})
+				return
+			}
+
 			log.Printf("failed to update profile: %v", err)
 			api.HandleError(
 				w,
@@ -180,27 +188,22 @@ func HandleUpdateProfile(pool *pgxpool.Pool) http.HandlerFunc {
 	}
 }
 
-func HandleGalleria(pool *pgxpool.Pool) http.HandlerFunc {
-	galleria := factories.MakeGalleriaService(pool)
+func HandlePublicProfile(pool *pgxpool.Pool) http.HandlerFunc {
+	profileService := factories.MakeProfileService(pool)
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		var page uint64 = 1
-		pageStr := r.URL.Query().Get("page")
-		if pageStr != "" {
-			p, err := strconv.ParseUint(pageStr, 10, 64)
-			if err != nil {
-				api.HandleError(w, http.StatusBadRequest, api.Error{
-					Message: "invalid page",
-					Details: "page must be a positive integer",
-				})
+		user, err := profileService.GetPublicProfile(
+			r.Context(),
+			viewerID(r),
+			chi.URLParam(r, "username"),
+		)
+		if err != nil {
+			if errors.Is(err, profile.ErrUserNotFound) {
+				api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
 				return
 			}
-			page = p
-		}
 
-		posts, err := galleria.Display(r.Context(), page)
-		if err != nil {
-			log.Printf("failed to get images: %v", err)
+			log.Printf("failed to get public profile: %v", err)
 			api.HandleError(
 				w,
 				http.StatusInternalServerError,
@@ -209,12 +212,171 @@ func HandleGalleria(pool *pgxpool.Pool) http.HandlerFunc {
 			return
 		}
 
-		if err = api.Encode(w, http.StatusOK, api.JSON{"posts": posts}); err != nil {
+		if err = api.Encode(w, http.StatusOK, user); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 	}
 }
 
+func HandleUserPosts(pool *pgxpool.Pool, recorder *analytics.Recorder) http.HandlerFunc {
+	galleriaService := factories.MakeGalleriaService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, ok := browseOptions(w, r)
+		if !ok {
+			return
+		}
+		opts.Username = chi.URLParam(r, "username")
+
+		writeBrowse(w, r, galleriaService, recorder, opts)
+	}
+}
+
+func HandleGalleria(pool *pgxpool.Pool, recorder *analytics.Recorder) http.HandlerFunc {
+	galleriaService := factories.MakeGalleriaService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, ok := browseOptions(w, r)
+		if !ok {
+			return
+		}
+
+		writeBrowse(w, r, galleriaService, recorder, opts)
+	}
+}
+
+// browseOptions reads the feed options from the query string, on failure the
+// error response is already written.
+func browseOptions(w http.ResponseWriter, r *http.Request) (galleria.BrowseOptions, bool) {
+	query := r.URL.Query()
+	opts := galleria.BrowseOptions{
+		Sort:     query.Get("sort"),
+		Period:   query.Get("period"),
+		Licenses: listParam(r, "license"),
+		Color:    query.Get("color"),
+		ViewerID: viewerID(r),
+		Cursor:   query.Get("cursor"),
+	}
+
+	// Offset pagination is kept for older clients, new ones should follow
+	// nextCursor instead.
+	page, ok := pageParam(w, r)
+	if !ok {
+		return opts, false
+	}
+	opts.Page = page
+
+	return opts, true
+}
+
+func writeBrowse(
+	w http.ResponseWriter,
+	r *http.Request,
+	galleriaService *galleria.Galleria,
+	recorder *analytics.Recorder,
+	opts galleria.BrowseOptions,
+) {
+	if problems := opts.Valid(); len(problems) > 0 {
+		api.HandleInvalidRequest(w, problems)
+		return
+	}
+
+	posts, nextCursor, err := galleriaService.Browse(r.Context(), opts)
+	if err != nil {
+		if errors.Is(err, galleria.ErrInvalidCursor) {
+			api.HandleError(w, http.StatusBadRequest, api.Error{
+				Message: err.Error(),
+				Details: "cursor must be a nextCursor returned by a previous request",
+			})
+			return
+		}
+
+		if errors.Is(err, galleria.ErrUserNotFound) {
+			api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+			return
+		}
+
+		log.Printf("failed to get images: %v", err)
+		api.HandleError(
+			w,
+			http.StatusInternalServerError,
+			api.Error{Message: "something went wrong, please try again"},
+		)
+		return
+	}
+	recordImpressions(recorder, r, posts)
+
+	resp := api.JSON{
+		"posts":      posts,
+		"nextCursor": nullableCursor(nextCursor),
+	}
+	if err = api.Encode(w, http.StatusOK, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// pageParam parses the optional page query parameter, on failure the error
+// response is already written.
+func pageParam(w http.ResponseWriter, r *http.Request) (page uint64, ok bool) {
+	pageStr := r.URL.Query().Get("page")
+	if pageStr == "" {
+		return 0, true
+	}
+
+	page, err := strconv.ParseUint(pageStr, 10, 64)
+	if err != nil {
+		api.HandleError(w, http.StatusBadRequest, api.Error{
+			Message: "invalid page",
+			Details: "page must be a positive integer",
+		})
+		return 0, false
+	}
+
+	return page, true
+}
+
+// uuidParam parses the URL parameter as a UUID, on failure the error response
+// is already written. label names the parameter in the error message.
+func uuidParam(w http.ResponseWriter, r *http.Request, name, label string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, name))
+	if err != nil {
+		api.HandleError(w, http.StatusBadRequest, api.Error{
+			Message: "invalid " + label,
+			Details: label + " must be a valid UUID",
+		})
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+// listParam splits a comma separated query parameter, it is nil when the
+// parameter is missing.
+func listParam(r *http.Request, name string) []string {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
+
+// viewerID returns the signed in user on routes open to anonymous visitors,
+// uuid.Nil when there is none.
+func viewerID(r *http.Request) uuid.UUID {
+	userID, _ := r.Context().Value(api.UserIDKey).(uuid.UUID)
+	return userID
+}
+
+// nullableCursor maps an exhausted cursor to null in JSON responses.
+func nullableCursor(cursor string) *string {
+	if cursor == "" {
+		return nil
+	}
+
+	return &cursor
+}
+
 func HandleAddPost(pool *pgxpool.Pool) http.HandlerFunc {
 	galleriaService := factories.MakeGalleriaService(pool)
 
@@ -248,8 +410,76 @@ func HandleAddPost(pool *pgxpool.Pool) http.HandlerFunc {
 	}
 }
 
+func HandleUpdatePost(pool *pgxpool.Pool) http.HandlerFunc {
+	galleriaService := factories.MakeGalleriaService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		postId, err := uuid.Parse(chi.URLParam(r, "postId"))
+		if err != nil {
+			api.HandleError(w, http.StatusBadRequest, api.Error{
+				Message: "invalid post id",
+				Details: "post id must be a valid UUID",
+			})
+			return
+		}
+
+		req, problems, err := api.DecodeValid[galleria.UpdateImageRequest](r)
+		if err != nil {
+			api.HandleInvalidRequest(w, problems)
+			return
+		}
+
+		err = galleriaService.UpdateImage(r.Context(), userID, postId, &req)
+		if err != nil {
+			if errors.Is(err, galleria.ErrImageNotFound) {
+				api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+				return
+			}
+
+			if errors.Is(err, galleria.ErrNotImageOwner) {
+				api.HandleError(w, http.StatusForbidden, api.Error{Message: err.Error()})
+				return
+			}
+
+			if errors.Is(err, galleria.ErrAlreadyPublished) {
+				api.HandleError(w, http.StatusConflict, api.Error{
+					Message: err.Error(),
+					Details: "published posts cannot go back to draft or be scheduled",
+				})
+				return
+			}
+
+			if errors.Is(err, galleria.ErrTooManyTags) {
+				api.HandleInvalidRequest(w, map[string]string{"tags": err.Error()})
+				return
+			}
+
+			if errors.Is(err, galleria.ErrNotScheduled) {
+				api.HandleError(w, http.StatusConflict, api.Error{
+					Message: err.Error(),
+					Details: "only scheduled posts can be rescheduled",
+				})
+				return
+			}
+
+			log.Printf("failed to update post: %v", err)
+			api.HandleError(
+				w,
+				http.StatusInternalServerError,
+				api.Error{Message: "something went wrong, please try again"},
+			)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
 type AddCommentRequest struct {
 	Comment string `json:"comment"`
+	// ParentID makes the comment a reply to another comment on the post.
+	ParentID *uuid.UUID `json:"parentId"`
 }
 
 func (r AddCommentRequest) Valid() (problems map[string]string) {
@@ -282,13 +512,25 @@ func HandleAddComment(pool *pgxpool.Pool) http.HandlerFunc {
 			return
 		}
 
-		commentID, err := galleriaService.AddComment(r.Context(), userID, postId, req.Comment)
+		var commentID uuid.UUID
+		if req.ParentID != nil {
+			commentID, err = galleriaService.Reply(r.Context(), userID, postId, *req.ParentID, req.Comment)
+		} else {
+			commentID, err = galleriaService.AddComment(r.Context(), userID, postId, req.Comment)
+		}
 		if err != nil {
-			if errors.Is(err, galleria.ErrImageNotFound) || errors.Is(err, galleria.ErrUserNotFound) {
+			if errors.Is(err, galleria.ErrImageNotFound) ||
+				errors.Is(err, galleria.ErrUserNotFound) ||
+				errors.Is(err, galleria.ErrCommentNotFound) {
 				api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
 				return
 			}
 
+			if errors.Is(err, galleria.ErrBlocked) {
+				api.HandleError(w, http.StatusForbidden, api.Error{Message: err.Error()})
+				return
+			}
+
 			log.Printf("failed to add comment: %v", err)
 			api.HandleError(
 				w,
@@ -317,7 +559,7 @@ func HandlePostComments(pool *pgxpool.Pool) http.HandlerFunc {
 			return
 		}
 
-		comments, err := galleriaService.GetComments(r.Context(), postId)
+		comments, err := galleriaService.GetComments(r.Context(), viewerID(r), postId)
 		if err != nil {
 			if errors.Is(err, galleria.ErrImageNotFound) {
 				api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
@@ -338,3 +580,11 @@ func HandlePostComments(pool *pgxpool.Pool) http.HandlerFunc {
 		}
 	}
 }
+
+func HandleLicenses() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := api.Encode(w, http.StatusOK, api.JSON{"licenses": licenses.All}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/imports"
+	"github.com/edulustosa/galleria/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	maxImportBytes = 200 << 20
+	// importTimeout replaces the read and write timeouts of the server for
+	// uploads, which are too large to be read in a few seconds. The write
+	// deadline runs from the start of the request, the response must not be
+	// cut off once the job is queued.
+	importTimeout = 5 * time.Minute
+)
+
+// importFormats maps the content types of manifests to their format.
+var importFormats = map[string]string{
+	"text/csv":                     "csv",
+	"application/json":             "json",
+	"application/zip":              "zip",
+	"application/x-zip-compressed": "zip",
+}
+
+// importFormat reads the format from the format query parameter, falling
+// back to the content type of the request.
+func importFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return importFormats[mediaType]
+}
+
+func handleImportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, imports.ErrInvalidManifest):
+		api.HandleError(w, http.StatusBadRequest, api.Error{Message: err.Error()})
+	case errors.Is(err, imports.ErrImportNotFound):
+		api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+	default:
+		log.Printf("failed to handle import: %v", err)
+		api.HandleError(
+			w,
+			http.StatusInternalServerError,
+			api.Error{Message: "something went wrong, please try again"},
+		)
+	}
+}
+
+// HandleStartImport queues the manifest in the body of the request, a CSV or
+// JSON manifest or a ZIP archive of images along with one, for import.
+func HandleStartImport(pool *pgxpool.Pool, files *storage.Disk) http.HandlerFunc {
+	importsService := factories.MakeImportsService(pool, files)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+
+		format := importFormat(r)
+		if !slices.Contains(imports.Formats, format) {
+			api.HandleError(w, http.StatusUnsupportedMediaType, api.Error{
+				Message: "unsupported manifest",
+				Details: "send a text/csv, application/json or application/zip body, or set format",
+			})
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		deadline := time.Now().Add(importTimeout)
+		if err := rc.SetReadDeadline(deadline); err != nil {
+			log.Printf("failed to extend read deadline: %v", err)
+		}
+
+		if err := rc.SetWriteDeadline(deadline.Add(time.Minute)); err != nil {
+			log.Printf("failed to extend write deadline: %v", err)
+		}
+
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			api.HandleError(w, http.StatusRequestEntityTooLarge, api.Error{
+				Message: "manifest is too large",
+				Details: "imports are limited to 200MB, split larger ones",
+			})
+			return
+		}
+
+		if err != nil {
+			api.HandleError(w, http.StatusBadRequest, api.Error{
+				Message: "failed to read manifest",
+				Details: err.Error(),
+			})
+			return
+		}
+
+		job, err := importsService.Start(r.Context(), userID, format, data)
+		if err != nil {
+			handleImportError(w, err)
+			return
+		}
+
+		w.Header().Set("Location", "/galleria/imports/"+job.ID.String())
+		if err = api.Encode(w, http.StatusAccepted, job); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// HandleGetImport reports the progress of an import and the result of the
+// rows processed so far.
+func HandleGetImport(pool *pgxpool.Pool, files *storage.Disk) http.HandlerFunc {
+	importsService := factories.MakeImportsService(pool, files)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		importID, ok := uuidParam(w, r, "importId", "import id")
+		if !ok {
+			return
+		}
+
+		job, err := importsService.Get(r.Context(), userID, importID)
+		if err != nil {
+			handleImportError(w, err)
+			return
+		}
+
+		if err = api.Encode(w, http.StatusOK, job); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
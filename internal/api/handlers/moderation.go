@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/moderation"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func handleModerationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, moderation.ErrTargetNotFound),
+		errors.Is(err, moderation.ErrReportNotFound):
+		api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+	case errors.Is(err, moderation.ErrAlreadyReported),
+		errors.Is(err, moderation.ErrAlreadyResolved):
+		api.HandleError(w, http.StatusConflict, api.Error{Message: err.Error()})
+	case errors.Is(err, moderation.ErrNotModerator):
+		api.HandleError(w, http.StatusForbidden, api.Error{Message: err.Error()})
+	case errors.Is(err, moderation.ErrInvalidAction):
+		api.HandleError(w, http.StatusBadRequest, api.Error{
+			Message: err.Error(),
+			Details: "users can only be dismissed or resolved",
+		})
+	case errors.Is(err, moderation.ErrInvalidStatus):
+		api.HandleError(w, http.StatusBadRequest, api.Error{
+			Message: err.Error(),
+			Details: "status must be one of open, resolved or dismissed",
+		})
+	case errors.Is(err, moderation.ErrInvalidCursor):
+		api.HandleError(w, http.StatusBadRequest, api.Error{
+			Message: err.Error(),
+			Details: "cursor must be a nextCursor returned by a previous request",
+		})
+	default:
+		log.Printf("failed to handle moderation: %v", err)
+		api.HandleError(
+			w,
+			http.StatusInternalServerError,
+			api.Error{Message: "something went wrong, please try again"},
+		)
+	}
+}
+
+func handleReport(pool *pgxpool.Pool, target repo.ReportTarget, param, label string) http.HandlerFunc {
+	moderationService := factories.MakeModerationService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		targetID, ok := uuidParam(w, r, param, label)
+		if !ok {
+			return
+		}
+
+		req, problems, err := api.DecodeValid[moderation.ReportRequest](r)
+		if err != nil {
+			api.HandleInvalidRequest(w, problems)
+			return
+		}
+
+		if err := moderationService.Report(r.Context(), userID, target, targetID, &req); err != nil {
+			handleModerationError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func HandleReportPost(pool *pgxpool.Pool) http.HandlerFunc {
+	return handleReport(pool, repo.ReportPost, "postId", "post id")
+}
+
+func HandleReportComment(pool *pgxpool.Pool) http.HandlerFunc {
+	return handleReport(pool, repo.ReportComment, "commentId", "comment id")
+}
+
+func HandleReportUser(pool *pgxpool.Pool) http.HandlerFunc {
+	return handleReport(pool, repo.ReportUser, "userId", "user id")
+}
+
+func HandleModerationQueue(pool *pgxpool.Pool) http.HandlerFunc {
+	moderationService := factories.MakeModerationService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		query := r.URL.Query()
+
+		reports, nextCursor, err := moderationService.Queue(
+			r.Context(),
+			userID,
+			query.Get("status"),
+			query.Get("cursor"),
+		)
+		if err != nil {
+			handleModerationError(w, err)
+			return
+		}
+
+		resp := api.JSON{
+			"reports":    reports,
+			"nextCursor": nullableCursor(nextCursor),
+		}
+		if err = api.Encode(w, http.StatusOK, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func HandleResolveReport(pool *pgxpool.Pool) http.HandlerFunc {
+	moderationService := factories.MakeModerationService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		reportID, ok := uuidParam(w, r, "reportId", "report id")
+		if !ok {
+			return
+		}
+
+		req, problems, err := api.DecodeValid[moderation.ResolveRequest](r)
+		if err != nil {
+			api.HandleInvalidRequest(w, problems)
+			return
+		}
+
+		if err := moderationService.Resolve(r.Context(), userID, reportID, &req); err != nil {
+			handleModerationError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
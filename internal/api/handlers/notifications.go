@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/notifications"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func handleNotificationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, notifications.ErrNotificationNotFound):
+		api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+	case errors.Is(err, notifications.ErrInvalidCursor):
+		api.HandleError(w, http.StatusBadRequest, api.Error{
+			Message: err.Error(),
+			Details: "cursor must be a nextCursor returned by a previous request",
+		})
+	default:
+		log.Printf("failed to handle notification: %v", err)
+		api.HandleError(
+			w,
+			http.StatusInternalServerError,
+			api.Error{Message: "something went wrong, please try again"},
+		)
+	}
+}
+
+func HandleGetNotifications(pool *pgxpool.Pool) http.HandlerFunc {
+	notificationsService := factories.MakeNotificationsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		inbox, err := notificationsService.List(r.Context(), userID, r.URL.Query().Get("cursor"))
+		if err != nil {
+			handleNotificationError(w, err)
+			return
+		}
+
+		resp := api.JSON{
+			"notifications": inbox.Notifications,
+			"unread":        inbox.Unread,
+			"nextCursor":    nullableCursor(inbox.NextCursor),
+		}
+		if err = api.Encode(w, http.StatusOK, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func HandleMarkNotificationRead(pool *pgxpool.Pool) http.HandlerFunc {
+	notificationsService := factories.MakeNotificationsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		notificationID, ok := uuidParam(w, r, "notificationId", "notification id")
+		if !ok {
+			return
+		}
+
+		if err := notificationsService.MarkRead(r.Context(), userID, notificationID); err != nil {
+			handleNotificationError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleMarkAllNotificationsRead(pool *pgxpool.Pool) http.HandlerFunc {
+	notificationsService := factories.MakeNotificationsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		if err := notificationsService.MarkAllRead(r.Context(), userID); err != nil {
+			handleNotificationError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
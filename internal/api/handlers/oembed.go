@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/oembed"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// dimensionParam reads an optional positive integer from the query, zero
+// when it is not set.
+func dimensionParam(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, true
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		api.HandleError(w, http.StatusBadRequest, api.Error{
+			Message: "invalid " + name,
+			Details: name + " must be a positive integer",
+		})
+		return 0, false
+	}
+
+	return value, true
+}
+
+// HandleOEmbed serves oEmbed responses for the urls of posts, only in json.
+func HandleOEmbed(pool *pgxpool.Pool, publicURL string) http.HandlerFunc {
+	oembedService := factories.MakeOEmbedService(pool, publicURL)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if format := query.Get("format"); format != "" && format != "json" {
+			api.HandleError(w, http.StatusNotImplemented, api.Error{
+				Message: "unsupported format",
+				Details: "only the json format is supported",
+			})
+			return
+		}
+
+		req := oembed.Request{URL: query.Get("url")}
+		if req.URL == "" {
+			api.HandleError(w, http.StatusBadRequest, api.Error{
+				Message: "missing url",
+				Details: "url must be the address of a post",
+			})
+			return
+		}
+
+		var ok bool
+		if req.MaxWidth, ok = dimensionParam(w, r, "maxwidth"); !ok {
+			return
+		}
+
+		if req.MaxHeight, ok = dimensionParam(w, r, "maxheight"); !ok {
+			return
+		}
+
+		res, err := oembedService.Resolve(r.Context(), req)
+		if err != nil {
+			switch {
+			case errors.Is(err, oembed.ErrInvalidURL), errors.Is(err, oembed.ErrPostNotFound):
+				api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+			default:
+				log.Printf("failed to resolve oembed: %v", err)
+				api.HandleError(
+					w,
+					http.StatusInternalServerError,
+					api.Error{Message: "something went wrong, please try again"},
+				)
+			}
+			return
+		}
+
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", oembed.CacheAge))
+		if err = api.Encode(w, http.StatusOK, res); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/reactions"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type reactionAction func(ctx context.Context, userID, targetID uuid.UUID, emoji string) error
+
+func handleReaction(idParam string, action reactionAction) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		targetID, err := uuid.Parse(chi.URLParam(r, idParam))
+		if err != nil {
+			api.HandleError(w, http.StatusBadRequest, api.Error{
+				Message: "invalid id",
+				Details: "id must be a valid UUID",
+			})
+			return
+		}
+
+		emoji, err := url.PathUnescape(chi.URLParam(r, "emoji"))
+		if err != nil {
+			api.HandleError(w, http.StatusBadRequest, api.Error{Message: "invalid emoji"})
+			return
+		}
+
+		err = action(r.Context(), userID, targetID, emoji)
+		if err != nil {
+			if errors.Is(err, reactions.ErrInvalidEmoji) {
+				api.HandleError(w, http.StatusBadRequest, api.Error{
+					Message: err.Error(),
+					Details: "emoji must be one of the allowed reactions",
+				})
+				return
+			}
+
+			if errors.Is(err, reactions.ErrImageNotFound) || errors.Is(err, reactions.ErrCommentNotFound) {
+				api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+				return
+			}
+
+			log.Printf("failed to update reaction: %v", err)
+			api.HandleError(
+				w,
+				http.StatusInternalServerError,
+				api.Error{Message: "something went wrong, please try again"},
+			)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleAddPostReaction(pool *pgxpool.Pool) http.HandlerFunc {
+	reactionsService := factories.MakeReactionsService(pool)
+	return handleReaction("postId", reactionsService.ReactToImage)
+}
+
+func HandleRemovePostReaction(pool *pgxpool.Pool) http.HandlerFunc {
+	reactionsService := factories.MakeReactionsService(pool)
+	return handleReaction("postId", reactionsService.UnreactToImage)
+}
+
+func HandleAddCommentReaction(pool *pgxpool.Pool) http.HandlerFunc {
+	reactionsService := factories.MakeReactionsService(pool)
+	return handleReaction("commentId", reactionsService.ReactToComment)
+}
+
+func HandleRemoveCommentReaction(pool *pgxpool.Pool) http.HandlerFunc {
+	reactionsService := factories.MakeReactionsService(pool)
+	return handleReaction("commentId", reactionsService.UnreactToComment)
+}
+
+func HandleAllowedReactions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := api.Encode(w, http.StatusOK, api.JSON{"reactions": reactions.Allowed}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
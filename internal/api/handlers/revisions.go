@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func handleRevisionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, galleria.ErrImageNotFound),
+		errors.Is(err, galleria.ErrRevisionNotFound),
+		errors.Is(err, galleria.ErrUserNotFound):
+		api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+	case errors.Is(err, galleria.ErrNotImageOwner):
+		api.HandleError(w, http.StatusForbidden, api.Error{
+			Message: err.Error(),
+			Details: "only the owner of the post and moderators can see its revisions",
+		})
+	case errors.Is(err, galleria.ErrTooManyTags):
+		api.HandleInvalidRequest(w, map[string]string{"tags": err.Error()})
+	default:
+		log.Printf("failed to handle revisions: %v", err)
+		api.HandleError(
+			w,
+			http.StatusInternalServerError,
+			api.Error{Message: "something went wrong, please try again"},
+		)
+	}
+}
+
+func HandlePostRevisions(pool *pgxpool.Pool) http.HandlerFunc {
+	galleriaService := factories.MakeGalleriaService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		postID, ok := uuidParam(w, r, "postId", "post id")
+		if !ok {
+			return
+		}
+
+		revisions, err := galleriaService.Revisions(r.Context(), userID, postID)
+		if err != nil {
+			handleRevisionError(w, err)
+			return
+		}
+
+		if err = api.Encode(w, http.StatusOK, api.JSON{"revisions": revisions}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func HandleRevertPost(pool *pgxpool.Pool) http.HandlerFunc {
+	galleriaService := factories.MakeGalleriaService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		postID, ok := uuidParam(w, r, "postId", "post id")
+		if !ok {
+			return
+		}
+
+		revision, err := strconv.Atoi(chi.URLParam(r, "revision"))
+		if err != nil || revision < 1 {
+			api.HandleError(w, http.StatusBadRequest, api.Error{
+				Message: "invalid revision",
+				Details: "revision must be a positive integer",
+			})
+			return
+		}
+
+		if err := galleriaService.Revert(r.Context(), userID, postID, revision); err != nil {
+			handleRevisionError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
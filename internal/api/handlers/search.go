@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func HandleSearch(pool *pgxpool.Pool) http.HandlerFunc {
+	galleriaService := factories.MakeGalleriaService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		opts := galleria.SearchOptions{
+			Query:    query.Get("q"),
+			Language: query.Get("lang"),
+			Licenses: listParam(r, "license"),
+			Cursor:   query.Get("cursor"),
+		}
+
+		page, ok := pageParam(w, r)
+		if !ok {
+			return
+		}
+		opts.Page = page
+
+		if problems := opts.Valid(); len(problems) > 0 {
+			api.HandleInvalidRequest(w, problems)
+			return
+		}
+
+		results, nextCursor, err := galleriaService.Search(r.Context(), opts)
+		if err != nil {
+			if errors.Is(err, galleria.ErrInvalidCursor) {
+				api.HandleError(w, http.StatusBadRequest, api.Error{
+					Message: err.Error(),
+					Details: "cursor must be a nextCursor returned by a previous request",
+				})
+				return
+			}
+
+			log.Printf("failed to search images: %v", err)
+			api.HandleError(
+				w,
+				http.StatusInternalServerError,
+				api.Error{Message: "something went wrong, please try again"},
+			)
+			return
+		}
+
+		resp := api.JSON{
+			"results":    results,
+			"nextCursor": nullableCursor(nextCursor),
+		}
+		if err = api.Encode(w, http.StatusOK, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/notifications"
+	"github.com/edulustosa/galleria/internal/stream"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// heartbeatInterval keeps idle streams from being closed by proxies.
+	heartbeatInterval = 15 * time.Second
+	// reconnectDelay is how long clients wait before reconnecting to a
+	// closed stream.
+	reconnectDelay = 3 * time.Second
+)
+
+type sseEvent struct {
+	ID   string
+	Name string
+	Data any
+}
+
+type streamSource struct {
+	// replay returns the events the client missed since lastEventID.
+	replay func(ctx context.Context, lastEventID string) ([]sseEvent, error)
+	// load returns the event for a row the broker announced.
+	load func(ctx context.Context, id uuid.UUID) (sseEvent, error)
+}
+
+func writeEvent(w http.ResponseWriter, event sseEvent) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Name, data)
+	return err
+}
+
+// serveStream sends the events of topic as Server-Sent Events until the client
+// leaves or the broker drops the subscription. Clients reconnecting with
+// Last-Event-ID first get what they missed.
+func serveStream(
+	w http.ResponseWriter,
+	r *http.Request,
+	broker *stream.Broker,
+	topic string,
+	source streamSource,
+) {
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+
+	// The server write timeout is meant for regular requests, streams stay
+	// open for as long as the client wants.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("failed to clear stream write deadline: %v", err)
+	}
+
+	// Subscribing before replaying means nothing is lost in between, events
+	// already replayed are skipped when the broker delivers them.
+	messages, unsubscribe := broker.Subscribe(topic)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds()); err != nil {
+		return
+	}
+
+	replayed := make(map[string]bool)
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		events, err := source.replay(ctx, lastEventID)
+		if err != nil && !errors.Is(err, galleria.ErrInvalidCursor) &&
+			!errors.Is(err, notifications.ErrInvalidCursor) {
+			log.Printf("failed to replay stream %s: %v", topic, err)
+		}
+
+		for _, event := range events {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			replayed[event.ID] = true
+		}
+	}
+
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			event, err := source.load(ctx, msg.ID)
+			if err != nil {
+				// The row may be gone already, there is nothing to send.
+				continue
+			}
+
+			if replayed[event.ID] {
+				continue
+			}
+
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func commentEvent(comment *models.Comment) sseEvent {
+	return sseEvent{
+		ID:   galleria.CommentEventID(comment),
+		Name: "comment",
+		Data: comment,
+	}
+}
+
+func HandleCommentStream(pool *pgxpool.Pool, broker *stream.Broker) http.HandlerFunc {
+	galleriaService := factories.MakeGalleriaService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		postID, ok := uuidParam(w, r, "postId", "post id")
+		if !ok {
+			return
+		}
+
+		viewer := viewerID(r)
+		if _, err := galleriaService.GetImage(r.Context(), viewer, postID); err != nil {
+			api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+			return
+		}
+
+		serveStream(w, r, broker, stream.PostTopic(postID), streamSource{
+			replay: func(ctx context.Context, lastEventID string) ([]sseEvent, error) {
+				comments, err := galleriaService.CommentsAfter(ctx, viewer, postID, lastEventID)
+				if err != nil {
+					return nil, err
+				}
+
+				events := make([]sseEvent, 0, len(comments))
+				for i := range comments {
+					events = append(events, commentEvent(&comments[i]))
+				}
+
+				return events, nil
+			},
+			// Comments hidden from the viewer fail to load and are not sent.
+			load: func(ctx context.Context, id uuid.UUID) (sseEvent, error) {
+				comment, err := galleriaService.GetComment(ctx, viewer, id)
+				if err != nil {
+					return sseEvent{}, err
+				}
+
+				return commentEvent(comment), nil
+			},
+		})
+	}
+}
+
+func notificationEvent(notification *models.Notification) sseEvent {
+	return sseEvent{
+		ID:   notifications.EventID(notification),
+		Name: "notification",
+		Data: notification,
+	}
+}
+
+func HandleNotificationStream(pool *pgxpool.Pool, broker *stream.Broker) http.HandlerFunc {
+	notificationsService := factories.MakeNotificationsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+
+		serveStream(w, r, broker, stream.UserTopic(userID), streamSource{
+			replay: func(ctx context.Context, lastEventID string) ([]sseEvent, error) {
+				missed, err := notificationsService.After(ctx, userID, lastEventID)
+				if err != nil {
+					return nil, err
+				}
+
+				events := make([]sseEvent, 0, len(missed))
+				for i := range missed {
+					events = append(events, notificationEvent(&missed[i]))
+				}
+
+				return events, nil
+			},
+			load: func(ctx context.Context, id uuid.UUID) (sseEvent, error) {
+				notification, err := notificationsService.Get(ctx, userID, id)
+				if err != nil {
+					return sseEvent{}, err
+				}
+
+				return notificationEvent(notification), nil
+			},
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/edulustosa/galleria/internal/analytics"
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/tags"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func HandleTagPosts(pool *pgxpool.Pool, recorder *analytics.Recorder) http.HandlerFunc {
+	galleriaService := factories.MakeGalleriaService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, ok := browseOptions(w, r)
+		if !ok {
+			return
+		}
+		opts.Tag = chi.URLParam(r, "tag")
+
+		writeBrowse(w, r, galleriaService, recorder, opts)
+	}
+}
+
+func HandlePopularTags(pool *pgxpool.Pool) http.HandlerFunc {
+	tagsService := factories.MakeTagsService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		period := r.URL.Query().Get("period")
+		if period == "" {
+			period = "week"
+		}
+
+		popular, err := tagsService.Popular(r.Context(), period)
+		if err != nil {
+			if errors.Is(err, tags.ErrInvalidPeriod) {
+				api.HandleError(w, http.StatusBadRequest, api.Error{
+					Message: err.Error(),
+					Details: "period must be one of day, week, month or all",
+				})
+				return
+			}
+
+			log.Printf("failed to get popular tags: %v", err)
+			api.HandleError(
+				w,
+				http.StatusInternalServerError,
+				api.Error{Message: "something went wrong, please try again"},
+			)
+			return
+		}
+
+		if err = api.Encode(w, http.StatusOK, api.JSON{"tags": popular}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/edulustosa/galleria/internal/api"
+	"github.com/edulustosa/galleria/internal/factories"
+	"github.com/edulustosa/galleria/internal/trash"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func handleTrashError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, trash.ErrImageNotFound),
+		errors.Is(err, trash.ErrCommentNotFound),
+		errors.Is(err, trash.ErrNotInTrash):
+		api.HandleError(w, http.StatusNotFound, api.Error{Message: err.Error()})
+	case errors.Is(err, trash.ErrNotOwner):
+		api.HandleError(w, http.StatusForbidden, api.Error{Message: err.Error()})
+	default:
+		log.Printf("failed to handle trash: %v", err)
+		api.HandleError(
+			w,
+			http.StatusInternalServerError,
+			api.Error{Message: "something went wrong, please try again"},
+		)
+	}
+}
+
+type trashAction func(t *trash.Trash, ctx context.Context, userID, targetID uuid.UUID) error
+
+func handleTrashAction(pool *pgxpool.Pool, action trashAction, param, label string) http.HandlerFunc {
+	trashService := factories.MakeTrashService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		targetID, ok := uuidParam(w, r, param, label)
+		if !ok {
+			return
+		}
+
+		if err := action(trashService, r.Context(), userID, targetID); err != nil {
+			handleTrashError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func HandleDeletePost(pool *pgxpool.Pool) http.HandlerFunc {
+	return handleTrashAction(pool, (*trash.Trash).DeleteImage, "postId", "post id")
+}
+
+func HandleRestorePost(pool *pgxpool.Pool) http.HandlerFunc {
+	return handleTrashAction(pool, (*trash.Trash).RestoreImage, "postId", "post id")
+}
+
+func HandleDeleteComment(pool *pgxpool.Pool) http.HandlerFunc {
+	return handleTrashAction(pool, (*trash.Trash).DeleteComment, "commentId", "comment id")
+}
+
+func HandleRestoreComment(pool *pgxpool.Pool) http.HandlerFunc {
+	return handleTrashAction(pool, (*trash.Trash).RestoreComment, "commentId", "comment id")
+}
+
+func HandleGetTrash(pool *pgxpool.Pool) http.HandlerFunc {
+	trashService := factories.MakeTrashService(pool)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(api.UserIDKey).(uuid.UUID)
+		items, err := trashService.List(r.Context(), userID)
+		if err != nil {
+			handleTrashError(w, err)
+			return
+		}
+
+		if err = api.Encode(w, http.StatusOK, items); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
@@ -92,3 +92,23 @@ func JWTAuthMiddleware(jwtKey []byte) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// OptionalJWTAuthMiddleware authenticates requests carrying a token and lets
+// anonymous ones through without a user ID in the context. Invalid tokens are
+// still rejected.
+func OptionalJWTAuthMiddleware(jwtKey []byte) func(http.Handler) http.Handler {
+	auth := JWTAuthMiddleware(jwtKey)
+
+	return func(next http.Handler) http.Handler {
+		authenticated := auth(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
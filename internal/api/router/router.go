@@ -3,15 +3,25 @@ package router
 import (
 	"net/http"
 
+	"github.com/edulustosa/galleria/internal/analytics"
 	"github.com/edulustosa/galleria/internal/api/handlers"
 	"github.com/edulustosa/galleria/internal/api/middlewares"
+	"github.com/edulustosa/galleria/internal/storage"
+	"github.com/edulustosa/galleria/internal/stream"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewServer(pool *pgxpool.Pool, jwtKey string) http.Handler {
+func NewServer(
+	pool *pgxpool.Pool,
+	jwtKey string,
+	broker *stream.Broker,
+	recorder *analytics.Recorder,
+	files *storage.Disk,
+	publicURL string,
+) http.Handler {
 	r := chi.NewMux()
 
 	corsMiddleware := cors.Handler(cors.Options{
@@ -31,26 +41,107 @@ func NewServer(pool *pgxpool.Pool, jwtKey string) http.Handler {
 		corsMiddleware,
 	)
 
-	addRoutes(r, pool, jwtKey)
+	addRoutes(r, pool, jwtKey, broker, recorder, files, publicURL)
 
 	return r
 }
 
-func addRoutes(r chi.Router, pool *pgxpool.Pool, jwtKey string) {
+func addRoutes(
+	r chi.Router,
+	pool *pgxpool.Pool,
+	jwtKey string,
+	broker *stream.Broker,
+	recorder *analytics.Recorder,
+	files *storage.Disk,
+	publicURL string,
+) {
 	r.Post("/register", handlers.HandleRegister(pool))
 	r.Post("/login", handlers.HandleLogin(pool, jwtKey))
 
-	r.Get("/galleria", handlers.HandleGalleria(pool))
-	r.Get("/galleria/posts/{postId}/comments", handlers.HandlePostComments(pool))
+	r.Get("/galleria/search", handlers.HandleSearch(pool))
+	r.Get("/reactions", handlers.HandleAllowedReactions())
+	r.Get("/licenses", handlers.HandleLicenses())
+	r.Get("/tags/popular", handlers.HandlePopularTags(pool))
+	r.Get("/users/{userId}/followers", handlers.HandleFollowers(pool))
+	r.Get("/users/{userId}/following", handlers.HandleFollowing(pool))
+	r.Handle("/files/"+storage.ImagesDir+"/*", files.PublicHandler())
+	r.Get("/oembed", handlers.HandleOEmbed(pool, publicURL))
+	r.Get("/galleria.rss", handlers.HandleGalleriaRSS(pool, publicURL))
+	r.Get("/galleria.atom", handlers.HandleGalleriaAtom(pool, publicURL))
+	r.Get("/users/{username}/feed.atom", handlers.HandleUserAtom(pool, publicURL))
+
+	// Open to anyone, signed in users also get their private posts and have
+	// what they muted or blocked left out.
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.OptionalJWTAuthMiddleware([]byte(jwtKey)))
+
+		r.Get("/galleria", handlers.HandleGalleria(pool, recorder))
+		r.Get("/galleria/posts/{postId}", handlers.HandleGetPost(pool, recorder))
+		r.Get("/galleria/posts/{postId}/comments", handlers.HandlePostComments(pool))
+		r.Get("/galleria/posts/{postId}/comments/stream", handlers.HandleCommentStream(pool, broker))
+		r.Get("/albums/{albumId}", handlers.HandleGetAlbum(pool))
+		r.Get("/tags/{tag}/posts", handlers.HandleTagPosts(pool, recorder))
+		r.Get("/users/{username}", handlers.HandlePublicProfile(pool))
+		r.Get("/users/{username}/posts", handlers.HandleUserPosts(pool, recorder))
+		r.Get("/users/{username}/albums", handlers.HandlePublicAlbums(pool))
+	})
 
 	r.Group(func(r chi.Router) {
 		r.Use(middlewares.JWTAuthMiddleware([]byte(jwtKey)))
 
 		r.Get("/profile", handlers.HandleGetUserProfile(pool))
 		r.Get("/profile/images", handlers.HandleGetUserImages(pool))
+		r.Get("/profile/images/export", handlers.HandleExportImages(pool, files))
+		r.Get("/profile/exports/{exportId}", handlers.HandleGetExport(pool, files))
+		r.Get("/profile/exports/{exportId}/download", handlers.HandleDownloadExport(pool, files))
 		r.Patch("/profile", handlers.HandleUpdateProfile(pool))
+		r.Get("/profile/albums", handlers.HandleGetUserAlbums(pool))
+		r.Get("/profile/bookmarks", handlers.HandleGetBookmarks(pool))
+		r.Get("/profile/analytics", handlers.HandleAnalytics(pool))
+		r.Get("/profile/trash", handlers.HandleGetTrash(pool))
 
 		r.Post("/galleria/posts/{postId}", handlers.HandleAddComment(pool))
+		r.Patch("/galleria/posts/{postId}", handlers.HandleUpdatePost(pool))
+		r.Delete("/galleria/posts/{postId}", handlers.HandleDeletePost(pool))
+		r.Post("/galleria/posts/{postId}/restore", handlers.HandleRestorePost(pool))
+		r.Get("/galleria/posts/{postId}/revisions", handlers.HandlePostRevisions(pool))
+		r.Post("/galleria/posts/{postId}/revisions/{revision}/revert", handlers.HandleRevertPost(pool))
+		r.Delete("/galleria/comments/{commentId}", handlers.HandleDeleteComment(pool))
+		r.Post("/galleria/comments/{commentId}/restore", handlers.HandleRestoreComment(pool))
 		r.Post("/galleria", handlers.HandleAddPost(pool))
+		r.Post("/galleria/imports", handlers.HandleStartImport(pool, files))
+		r.Get("/galleria/imports/{importId}", handlers.HandleGetImport(pool, files))
+		r.Put("/galleria/posts/{postId}/bookmark", handlers.HandleAddBookmark(pool))
+		r.Delete("/galleria/posts/{postId}/bookmark", handlers.HandleRemoveBookmark(pool))
+		r.Post("/galleria/posts/{postId}/report", handlers.HandleReportPost(pool))
+		r.Post("/galleria/comments/{commentId}/report", handlers.HandleReportComment(pool))
+
+		r.Put("/galleria/posts/{postId}/reactions/{emoji}", handlers.HandleAddPostReaction(pool))
+		r.Delete("/galleria/posts/{postId}/reactions/{emoji}", handlers.HandleRemovePostReaction(pool))
+		r.Put("/galleria/comments/{commentId}/reactions/{emoji}", handlers.HandleAddCommentReaction(pool))
+		r.Delete("/galleria/comments/{commentId}/reactions/{emoji}", handlers.HandleRemoveCommentReaction(pool))
+
+		r.Post("/albums", handlers.HandleCreateAlbum(pool))
+		r.Patch("/albums/{albumId}", handlers.HandleUpdateAlbum(pool))
+		r.Delete("/albums/{albumId}", handlers.HandleDeleteAlbum(pool))
+		r.Put("/albums/{albumId}/images", handlers.HandleReorderAlbum(pool))
+		r.Put("/albums/{albumId}/images/{imageId}", handlers.HandleAddAlbumImage(pool))
+		r.Delete("/albums/{albumId}/images/{imageId}", handlers.HandleRemoveAlbumImage(pool))
+
+		r.Get("/feed", handlers.HandleHomeFeed(pool, recorder))
+		r.Get("/notifications", handlers.HandleGetNotifications(pool))
+		r.Get("/notifications/stream", handlers.HandleNotificationStream(pool, broker))
+		r.Post("/notifications/read", handlers.HandleMarkAllNotificationsRead(pool))
+		r.Post("/notifications/{notificationId}/read", handlers.HandleMarkNotificationRead(pool))
+		r.Put("/users/{userId}/follow", handlers.HandleFollow(pool))
+		r.Delete("/users/{userId}/follow", handlers.HandleUnfollow(pool))
+		r.Post("/users/{userId}/report", handlers.HandleReportUser(pool))
+		r.Put("/users/{userId}/block", handlers.HandleBlock(pool))
+		r.Delete("/users/{userId}/block", handlers.HandleUnblock(pool))
+		r.Put("/users/{userId}/mute", handlers.HandleMute(pool))
+		r.Delete("/users/{userId}/mute", handlers.HandleUnmute(pool))
+
+		r.Get("/moderation/reports", handlers.HandleModerationQueue(pool))
+		r.Post("/moderation/reports/{reportId}/resolve", handlers.HandleResolveReport(pool))
 	})
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/edulustosa/galleria/internal/database/models"
 	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/mentions"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -42,6 +43,8 @@ func (r RegisterRequest) Valid() (problems map[string]string) {
 
 	if len(r.Username) < 3 || len(r.Username) > 32 {
 		problems["username"] = "username must be between 3 and 32 characters"
+	} else if !mentions.ValidUsername(r.Username) {
+		problems["username"] = "username can only have letters, digits, underscores and dots, not at its ends"
 	}
 
 	return problems
@@ -49,6 +52,7 @@ func (r RegisterRequest) Valid() (problems map[string]string) {
 
 var (
 	ErrUserAlreadyExists  = errors.New("user already exists")
+	ErrUsernameTaken      = errors.New("username is already taken")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 )
 
@@ -61,6 +65,11 @@ func (a *Auth) Register(
 		return uuid.Nil, ErrUserAlreadyExists
 	}
 
+	_, err = a.usersRepository.FindByUsername(ctx, req.Username)
+	if err == nil {
+		return uuid.Nil, ErrUsernameTaken
+	}
+
 	passwordHashBytes, err := bcrypt.GenerateFromPassword(
 		[]byte(req.Password),
 		bcrypt.DefaultCost,
@@ -75,7 +84,12 @@ func (a *Auth) Register(
 		PasswordHash: string(passwordHashBytes),
 	}
 
-	return a.usersRepository.Create(ctx, user)
+	id, err := a.usersRepository.Create(ctx, user)
+	if errors.Is(err, repo.ErrUsernameTaken) {
+		return uuid.Nil, ErrUsernameTaken
+	}
+
+	return id, err
 }
 
 type LoginRequest struct {
@@ -0,0 +1,70 @@
+package blocks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+// Blocks lets users protect themselves. Blocked users cannot comment on their
+// posts, follow them or see their profile. Muted users are only filtered out
+// of their feeds and comment lists, without being told.
+type Blocks struct {
+	blocksRepository repo.BlocksRepository
+	usersRepository  repo.UsersRepository
+}
+
+func New(
+	blocksRepository repo.BlocksRepository,
+	usersRepository repo.UsersRepository,
+) *Blocks {
+	return &Blocks{
+		blocksRepository: blocksRepository,
+		usersRepository:  usersRepository,
+	}
+}
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrCannotBlockSelf = errors.New("users cannot block or mute themselves")
+)
+
+func (b *Blocks) checkTarget(ctx context.Context, userID, targetID uuid.UUID) error {
+	if userID == targetID {
+		return ErrCannotBlockSelf
+	}
+
+	if _, err := b.usersRepository.FindByID(ctx, targetID); err != nil {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// Block is idempotent and also ends the follows between both users.
+func (b *Blocks) Block(ctx context.Context, userID, blockedID uuid.UUID) error {
+	if err := b.checkTarget(ctx, userID, blockedID); err != nil {
+		return err
+	}
+
+	return b.blocksRepository.Block(ctx, userID, blockedID)
+}
+
+func (b *Blocks) Unblock(ctx context.Context, userID, blockedID uuid.UUID) error {
+	return b.blocksRepository.Unblock(ctx, userID, blockedID)
+}
+
+// Mute is idempotent.
+func (b *Blocks) Mute(ctx context.Context, userID, mutedID uuid.UUID) error {
+	if err := b.checkTarget(ctx, userID, mutedID); err != nil {
+		return err
+	}
+
+	return b.blocksRepository.Mute(ctx, userID, mutedID)
+}
+
+func (b *Blocks) Unmute(ctx context.Context, userID, mutedID uuid.UUID) error {
+	return b.blocksRepository.Unmute(ctx, userID, mutedID)
+}
@@ -0,0 +1,66 @@
+package bookmarks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+type Bookmarks struct {
+	bookmarksRepository repo.BookmarksRepository
+	imagesRepository    repo.ImagesRepository
+}
+
+func New(
+	bookmarksRepository repo.BookmarksRepository,
+	imagesRepository repo.ImagesRepository,
+) *Bookmarks {
+	return &Bookmarks{
+		bookmarksRepository: bookmarksRepository,
+		imagesRepository:    imagesRepository,
+	}
+}
+
+var (
+	ErrImageNotFound = errors.New("image not found")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Save is idempotent, saving a post twice keeps the original save time.
+func (b *Bookmarks) Save(ctx context.Context, userID, imageID uuid.UUID) error {
+	if _, err := b.imagesRepository.FindByID(ctx, imageID, userID); err != nil {
+		return ErrImageNotFound
+	}
+
+	return b.bookmarksRepository.Add(ctx, userID, imageID)
+}
+
+func (b *Bookmarks) Unsave(ctx context.Context, userID, imageID uuid.UUID) error {
+	return b.bookmarksRepository.Remove(ctx, userID, imageID)
+}
+
+// List returns a page of the posts the user saved and the cursor to the next
+// page, which is empty once there is nothing left to fetch.
+func (b *Bookmarks) List(
+	ctx context.Context,
+	userID uuid.UUID,
+	cursor string,
+) (posts []models.Post, nextCursor string, err error) {
+	var after *repo.Cursor
+	if cursor != "" {
+		after, err = repo.ParseCursor(cursor)
+		if err != nil || after.Kind != repo.CursorBookmarks {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+
+	posts, next, err := b.bookmarksRepository.FindByUserID(ctx, userID, after)
+	if err != nil || next == nil {
+		return posts, "", err
+	}
+
+	return posts, next.String(), nil
+}
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS image_reactions (
+    "user_id" uuid NOT NULL,
+    "image_id" uuid NOT NULL,
+    "emoji" VARCHAR(16) NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, image_id, emoji),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (image_id) REFERENCES images (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS image_reactions_image_id_idx ON image_reactions (image_id);
+
+CREATE TABLE IF NOT EXISTS comment_reactions (
+    "user_id" uuid NOT NULL,
+    "comment_id" uuid NOT NULL,
+    "emoji" VARCHAR(16) NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, comment_id, emoji),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comments (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS comment_reactions_comment_id_idx ON comment_reactions (comment_id);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS images_created_at_id_idx ON images (created_at DESC, id DESC);
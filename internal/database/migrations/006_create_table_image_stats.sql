@@ -0,0 +1,94 @@
+-- image_stats keeps the engagement counters used to sort the feed, so that
+-- ranking never needs to aggregate reactions and comments on read.
+CREATE TABLE IF NOT EXISTS image_stats (
+    "image_id" uuid PRIMARY KEY NOT NULL,
+    "reactions_count" BIGINT NOT NULL DEFAULT 0,
+    "comments_count" BIGINT NOT NULL DEFAULT 0,
+    "hot_score" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP NOT NULL,
+    FOREIGN KEY (image_id) REFERENCES images (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS image_stats_top_idx
+    ON image_stats (reactions_count DESC, created_at DESC, image_id DESC);
+
+CREATE INDEX IF NOT EXISTS image_stats_trending_idx
+    ON image_stats (hot_score DESC, created_at DESC, image_id DESC);
+
+-- The trending score grows logarithmically with engagement and linearly with
+-- time, every 12.5 hours of age weighs as much as 10x the engagement. Newer
+-- posts outrank older ones without the score ever having to be recomputed.
+CREATE OR REPLACE FUNCTION image_hot_score(engagement BIGINT, created_at TIMESTAMP)
+RETURNS DOUBLE PRECISION AS $$
+    SELECT LOG(GREATEST(engagement, 1)) + EXTRACT(EPOCH FROM created_at) / 45000;
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION bump_image_stats(target uuid, reactions_delta INT, comments_delta INT)
+RETURNS VOID AS $$
+    UPDATE image_stats SET
+        reactions_count = reactions_count + reactions_delta,
+        comments_count = comments_count + comments_delta,
+        hot_score = image_hot_score(
+            reactions_count + reactions_delta + 2 * (comments_count + comments_delta),
+            created_at
+        )
+    WHERE image_id = target;
+$$ LANGUAGE SQL;
+
+CREATE OR REPLACE FUNCTION create_image_stats() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO image_stats (image_id, created_at, hot_score)
+    VALUES (NEW.id, NEW.created_at, image_hot_score(0, NEW.created_at));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION count_image_reaction() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM bump_image_stats(NEW.image_id, 1, 0);
+    ELSE
+        PERFORM bump_image_stats(OLD.image_id, -1, 0);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION count_image_comment() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM bump_image_stats(NEW.image_id, 0, 1);
+    ELSE
+        PERFORM bump_image_stats(OLD.image_id, 0, -1);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS images_create_stats ON images;
+CREATE TRIGGER images_create_stats
+    AFTER INSERT ON images
+    FOR EACH ROW EXECUTE FUNCTION create_image_stats();
+
+DROP TRIGGER IF EXISTS image_reactions_count ON image_reactions;
+CREATE TRIGGER image_reactions_count
+    AFTER INSERT OR DELETE ON image_reactions
+    FOR EACH ROW EXECUTE FUNCTION count_image_reaction();
+
+DROP TRIGGER IF EXISTS comments_count ON comments;
+CREATE TRIGGER comments_count
+    AFTER INSERT OR DELETE ON comments
+    FOR EACH ROW EXECUTE FUNCTION count_image_comment();
+
+INSERT INTO image_stats (image_id, reactions_count, comments_count, hot_score, created_at)
+SELECT
+    images.id,
+    (SELECT COUNT(*) FROM image_reactions WHERE image_reactions.image_id = images.id),
+    (SELECT COUNT(*) FROM comments WHERE comments.image_id = images.id),
+    0,
+    images.created_at
+FROM images
+ON CONFLICT (image_id) DO NOTHING;
+
+UPDATE image_stats
+SET hot_score = image_hot_score(reactions_count + 2 * comments_count, created_at);
@@ -0,0 +1,9 @@
+ALTER TABLE images ADD COLUMN IF NOT EXISTS "language" regconfig NOT NULL DEFAULT 'english';
+
+ALTER TABLE images ADD COLUMN IF NOT EXISTS "search_vector" tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector("language", COALESCE("title", '')), 'A') ||
+    setweight(to_tsvector("language", COALESCE("description", '')), 'B') ||
+    setweight(to_tsvector("language", COALESCE("author", '')), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS images_search_vector_idx ON images USING GIN (search_vector);
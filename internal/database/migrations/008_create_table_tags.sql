@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS tags (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT gen_random_uuid (),
+    "name" VARCHAR(50) NOT NULL UNIQUE,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS image_tags (
+    "image_id" uuid NOT NULL,
+    "tag_id" uuid NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (image_id, tag_id),
+    FOREIGN KEY (image_id) REFERENCES images (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS image_tags_tag_id_idx ON image_tags (tag_id, image_id);
+CREATE INDEX IF NOT EXISTS image_tags_created_at_idx ON image_tags (created_at);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS albums (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT gen_random_uuid (),
+    "user_id" uuid NOT NULL,
+    "title" VARCHAR(255) NOT NULL,
+    "description" VARCHAR(500),
+    "cover_image_id" uuid,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (cover_image_id) REFERENCES images (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS albums_user_id_idx ON albums (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS album_images (
+    "album_id" uuid NOT NULL,
+    "image_id" uuid NOT NULL,
+    "position" INTEGER NOT NULL,
+    "added_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (album_id, image_id),
+    FOREIGN KEY (album_id) REFERENCES albums (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (image_id) REFERENCES images (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS album_images_position_idx ON album_images (album_id, position);
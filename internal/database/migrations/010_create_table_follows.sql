@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS follows (
+    "follower_id" uuid NOT NULL,
+    "followee_id" uuid NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id),
+    FOREIGN KEY (follower_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (followee_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS follows_following_idx
+    ON follows (follower_id, created_at DESC, followee_id DESC);
+
+CREATE INDEX IF NOT EXISTS follows_followers_idx
+    ON follows (followee_id, created_at DESC, follower_id DESC);
+
+-- The home feed reads the latest posts of every followed account straight
+-- from this index and merges them, instead of sorting all of their posts.
+CREATE INDEX IF NOT EXISTS images_user_id_created_at_idx
+    ON images (user_id, created_at DESC, id DESC);
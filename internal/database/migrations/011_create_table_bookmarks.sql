@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS bookmarks (
+    "user_id" uuid NOT NULL,
+    "image_id" uuid NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, image_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (image_id) REFERENCES images (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS bookmarks_user_id_created_at_idx
+    ON bookmarks (user_id, created_at DESC, image_id DESC);
@@ -0,0 +1,14 @@
+-- Usernames identify public profiles, so they must be unique regardless of
+-- case. Accounts whose username only differs in case from an older one get
+-- the start of their id appended to it before the index is created, trimmed
+-- to stay within the 32 characters usernames can have.
+UPDATE users SET username = LEFT(users.username, 23) || '_' || LEFT(users.id::text, 8)
+FROM (
+    SELECT id, ROW_NUMBER() OVER (
+        PARTITION BY LOWER(username) ORDER BY created_at, id
+    ) AS rank
+    FROM users
+) AS ranked
+WHERE users.id = ranked.id AND ranked.rank > 1;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_idx ON users (LOWER(username));
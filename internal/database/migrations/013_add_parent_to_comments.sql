@@ -0,0 +1,4 @@
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS "parent_id" uuid
+    REFERENCES comments (id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS comments_parent_id_idx ON comments (parent_id);
@@ -0,0 +1,37 @@
+-- Events of the same type on the same target are grouped into a single
+-- notification while it is unread, notification_actors holds who caused them.
+-- Once read, the next event starts a new notification.
+CREATE TABLE IF NOT EXISTS notifications (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT gen_random_uuid (),
+    "user_id" uuid NOT NULL,
+    "type" VARCHAR(16) NOT NULL,
+    "image_id" uuid,
+    "comment_id" uuid,
+    "group_key" TEXT GENERATED ALWAYS AS (
+        type || ':' || COALESCE(comment_id::text, image_id::text, '')
+    ) STORED,
+    "read_at" TIMESTAMP,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (image_id) REFERENCES images (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comments (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS notifications_unread_group_idx
+    ON notifications (user_id, group_key) WHERE read_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS notifications_user_id_updated_at_idx
+    ON notifications (user_id, updated_at DESC, id DESC);
+
+CREATE TABLE IF NOT EXISTS notification_actors (
+    "notification_id" uuid NOT NULL,
+    "actor_id" uuid NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (notification_id, actor_id),
+    FOREIGN KEY (notification_id) REFERENCES notifications (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS notification_actors_created_at_idx
+    ON notification_actors (notification_id, created_at DESC);
@@ -0,0 +1,34 @@
+-- Changes streamed to clients are published on the galleria_events channel,
+-- every API replica listens to it and forwards them to its own subscribers.
+-- Payloads only carry the topic and the row id, well below the NOTIFY limit.
+CREATE OR REPLACE FUNCTION publish_event(topic TEXT, id uuid) RETURNS VOID AS $$
+    SELECT pg_notify('galleria_events', json_build_object('topic', topic, 'id', id)::text);
+$$ LANGUAGE SQL;
+
+CREATE OR REPLACE FUNCTION publish_comment() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM publish_event('post:' || NEW.image_id, NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION publish_notification() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM publish_event('user:' || NEW.user_id, NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS comments_publish ON comments;
+CREATE TRIGGER comments_publish
+    AFTER INSERT ON comments
+    FOR EACH ROW EXECUTE FUNCTION publish_comment();
+
+DROP TRIGGER IF EXISTS notifications_publish ON notifications;
+CREATE TRIGGER notifications_publish
+    AFTER INSERT OR UPDATE ON notifications
+    FOR EACH ROW EXECUTE FUNCTION publish_notification();
+
+-- Streams replay what a reconnecting client missed in creation order.
+CREATE INDEX IF NOT EXISTS comments_image_id_created_at_idx
+    ON comments (image_id, created_at, id);
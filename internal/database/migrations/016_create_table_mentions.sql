@@ -0,0 +1,25 @@
+-- Mentions are stored as spans of the text, offsets are counted in
+-- characters so clients can render them as links.
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    "comment_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "start_offset" INTEGER NOT NULL,
+    "end_offset" INTEGER NOT NULL,
+    PRIMARY KEY (comment_id, start_offset),
+    FOREIGN KEY (comment_id) REFERENCES comments (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS comment_mentions_user_id_idx ON comment_mentions (user_id);
+
+CREATE TABLE IF NOT EXISTS image_mentions (
+    "image_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "start_offset" INTEGER NOT NULL,
+    "end_offset" INTEGER NOT NULL,
+    PRIMARY KEY (image_id, start_offset),
+    FOREIGN KEY (image_id) REFERENCES images (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS image_mentions_user_id_idx ON image_mentions (user_id);
@@ -0,0 +1,34 @@
+-- Moderators are appointed directly in the database.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "is_moderator" BOOLEAN NOT NULL DEFAULT false;
+
+-- Hidden content stays reachable by id but is left out of feeds, search and
+-- comment lists.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS "hidden_at" TIMESTAMP;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS "hidden_at" TIMESTAMP;
+
+-- The target is not a foreign key so reports outlive the content they are
+-- about and removals can be audited.
+CREATE TABLE IF NOT EXISTS reports (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT gen_random_uuid (),
+    "reporter_id" uuid NOT NULL,
+    "target_type" VARCHAR(16) NOT NULL,
+    "target_id" uuid NOT NULL,
+    "reason" VARCHAR(16) NOT NULL,
+    "details" VARCHAR(500),
+    "status" VARCHAR(16) NOT NULL DEFAULT 'open',
+    "action" VARCHAR(16),
+    "resolved_by" uuid,
+    "resolved_at" TIMESTAMP,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (reporter_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (resolved_by) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+
+-- A user has at most one open report per target, reporting again is a no-op.
+CREATE UNIQUE INDEX IF NOT EXISTS reports_open_reporter_target_idx
+    ON reports (reporter_id, target_type, target_id) WHERE status = 'open';
+
+CREATE INDEX IF NOT EXISTS reports_status_created_at_idx
+    ON reports (status, created_at, id);
+
+CREATE INDEX IF NOT EXISTS reports_target_idx ON reports (target_type, target_id);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS blocks (
+    "blocker_id" uuid NOT NULL,
+    "blocked_id" uuid NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id),
+    FOREIGN KEY (blocker_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (blocked_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS mutes (
+    "muter_id" uuid NOT NULL,
+    "muted_id" uuid NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (muter_id, muted_id),
+    CHECK (muter_id <> muted_id),
+    FOREIGN KEY (muter_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (muted_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+-- Public posts are listed everywhere, unlisted ones are only reachable by
+-- their link and private ones only by their owner.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS "visibility" VARCHAR(16) NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'unlisted', 'private'));
+
+-- Feeds only ever read listed posts.
+CREATE INDEX IF NOT EXISTS images_listed_created_at_idx
+    ON images (created_at DESC, id DESC)
+    WHERE visibility = 'public' AND hidden_at IS NULL;
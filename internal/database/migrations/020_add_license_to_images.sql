@@ -0,0 +1,6 @@
+-- Licenses are validated by the application, new ones can be added without a
+-- migration.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "default_license" VARCHAR(32) NOT NULL DEFAULT 'all-rights-reserved';
+
+ALTER TABLE images ADD COLUMN IF NOT EXISTS "license" VARCHAR(32) NOT NULL DEFAULT 'all-rights-reserved';
+ALTER TABLE images ADD COLUMN IF NOT EXISTS "source_url" VARCHAR(512);
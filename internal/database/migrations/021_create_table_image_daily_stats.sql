@@ -0,0 +1,17 @@
+-- image_daily_stats rolls the engagement of posts up per day for the owner
+-- analytics. Views and impressions are deduplicated and counted in memory and
+-- flushed here in batches, likes and comments are recounted from their own
+-- tables by the same job, so requests never write to it.
+CREATE TABLE IF NOT EXISTS image_daily_stats (
+    "image_id" uuid NOT NULL,
+    "day" DATE NOT NULL,
+    "views" BIGINT NOT NULL DEFAULT 0,
+    "impressions" BIGINT NOT NULL DEFAULT 0,
+    "likes" BIGINT NOT NULL DEFAULT 0,
+    "comments" BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (image_id, day),
+    FOREIGN KEY (image_id) REFERENCES images (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS image_reactions_created_at_idx ON image_reactions (created_at);
+CREATE INDEX IF NOT EXISTS comments_created_at_idx ON comments (created_at);
@@ -0,0 +1,23 @@
+-- image_colors holds the dominant colors of images, extracted in the
+-- background after they are posted. Colors are kept in CIE Lab as well so
+-- that searching by color compares them the way people perceive them.
+CREATE TABLE IF NOT EXISTS image_colors (
+    "image_id" uuid NOT NULL,
+    "position" SMALLINT NOT NULL,
+    "hex" CHAR(7) NOT NULL,
+    "l" REAL NOT NULL,
+    "a" REAL NOT NULL,
+    "b" REAL NOT NULL,
+    -- weight is the share of the image covered by the color.
+    "weight" REAL NOT NULL,
+    PRIMARY KEY (image_id, position),
+    FOREIGN KEY (image_id) REFERENCES images (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+-- palette_extracted_at is reset when the url of the image changes, images
+-- still to be processed have it NULL.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS "palette_extracted_at" TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS images_palette_pending_idx
+    ON images (created_at)
+    WHERE palette_extracted_at IS NULL;
@@ -0,0 +1,42 @@
+-- Deleted posts and comments go to the trash of their author, where they can
+-- be restored until they are purged for good after the retention window.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS "deleted_at" TIMESTAMP;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS "deleted_at" TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS images_trash_idx
+    ON images (user_id, deleted_at DESC)
+    WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS comments_trash_idx
+    ON comments (user_id, deleted_at DESC)
+    WHERE deleted_at IS NOT NULL;
+
+-- Replies of other users outlive the comment they answer once it is purged,
+-- they become top level comments instead of being deleted with it.
+ALTER TABLE comments DROP CONSTRAINT IF EXISTS comments_parent_id_fkey;
+ALTER TABLE comments ADD CONSTRAINT comments_parent_id_fkey
+    FOREIGN KEY (parent_id) REFERENCES comments (id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+-- Comments in the trash are not counted, and purging them must not uncount
+-- them twice.
+CREATE OR REPLACE FUNCTION count_image_comment() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM bump_image_stats(NEW.image_id, 0, 1);
+    ELSIF TG_OP = 'DELETE' THEN
+        IF OLD.deleted_at IS NULL THEN
+            PERFORM bump_image_stats(OLD.image_id, 0, -1);
+        END IF;
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        PERFORM bump_image_stats(NEW.image_id, 0, -1);
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        PERFORM bump_image_stats(NEW.image_id, 0, 1);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS comments_count ON comments;
+CREATE TRIGGER comments_count
+    AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON comments
+    FOR EACH ROW EXECUTE FUNCTION count_image_comment();
@@ -0,0 +1,24 @@
+-- image_revisions keeps every version of the title, author, description and
+-- url of posts, so that what a post used to say can be told in disputes.
+-- Revision 1 is the post as first published, a new revision is only recorded
+-- when one of those fields changes.
+CREATE TABLE IF NOT EXISTS image_revisions (
+    "image_id" uuid NOT NULL,
+    "revision" INT NOT NULL,
+    "editor_id" uuid,
+    -- reverted_from is the revision the post was reverted to, if any.
+    "reverted_from" INT,
+    "title" VARCHAR(255) NOT NULL,
+    "author" VARCHAR(50),
+    "description" VARCHAR(500),
+    "url" VARCHAR(512) NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (image_id, revision),
+    FOREIGN KEY (image_id) REFERENCES images (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (editor_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+
+INSERT INTO image_revisions (image_id, revision, editor_id, title, author, description, url, created_at)
+SELECT id, 1, user_id, title, author, description, url, updated_at
+FROM images
+ON CONFLICT (image_id, revision) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Drafts and scheduled posts are only seen by their owner. Scheduled ones are
+-- published by the scheduler once publish_at is due, created_at is then moved
+-- to the time the post went live so that it shows up as new in feeds.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS "status" VARCHAR(16) NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'scheduled', 'published'));
+ALTER TABLE images ADD COLUMN IF NOT EXISTS "publish_at" TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS images_scheduled_idx
+    ON images (publish_at)
+    WHERE status = 'scheduled';
@@ -0,0 +1,22 @@
+-- import_jobs are bulk imports of posts from a manifest, processed in the
+-- background one row at a time. report holds the result of every processed
+-- row, so a job interrupted by a restart resumes where it stopped.
+CREATE TABLE IF NOT EXISTS import_jobs (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT gen_random_uuid (),
+    "user_id" uuid NOT NULL,
+    "format" VARCHAR(4) NOT NULL CHECK (format IN ('csv', 'json', 'zip')),
+    "status" VARCHAR(9) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    "total" INT NOT NULL,
+    "processed" INT NOT NULL DEFAULT 0,
+    "succeeded" INT NOT NULL DEFAULT 0,
+    "report" JSONB NOT NULL DEFAULT '[]'::jsonb,
+    "error" TEXT,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "finished_at" TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS import_jobs_unfinished_idx ON import_jobs (created_at)
+WHERE status IN ('pending', 'running');
@@ -0,0 +1,21 @@
+-- export_jobs build the ZIP archives of large galleries in the background.
+-- The archive is kept in storage until it expires, a user has at most one
+-- unfinished export at a time.
+CREATE TABLE IF NOT EXISTS export_jobs (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT gen_random_uuid (),
+    "user_id" uuid NOT NULL,
+    "status" VARCHAR(9) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    "total" INT NOT NULL,
+    "processed" INT NOT NULL DEFAULT 0,
+    "error" TEXT,
+    "created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "finished_at" TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS export_jobs_unfinished_idx ON export_jobs (user_id)
+WHERE status IN ('pending', 'running');
+
+CREATE INDEX IF NOT EXISTS export_jobs_finished_at_idx ON export_jobs (finished_at);
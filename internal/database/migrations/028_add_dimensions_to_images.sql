@@ -0,0 +1,7 @@
+-- width and height are read from the image along with its palette, they are
+-- unknown until then or when the image cannot be decoded. Palettes are
+-- extracted again so the dimensions of existing images are filled in.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS width INT;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS height INT;
+
+UPDATE images SET palette_extracted_at = NULL WHERE width IS NULL;
@@ -0,0 +1,8 @@
+-- Tags set by the owner of the image are explicit, the ones taken from the
+-- #hashtags of its description are not and go away with the hashtag.
+ALTER TABLE image_tags ADD COLUMN IF NOT EXISTS "explicit" BOOLEAN NOT NULL DEFAULT true;
+
+UPDATE image_tags SET explicit = false
+FROM images, tags
+WHERE images.id = image_tags.image_id AND tags.id = image_tags.tag_id
+    AND images.description ~* ('(^|[^[:alnum:]_&])#' || tags.name || '($|[^[:alnum:]_])');
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -12,35 +14,276 @@ type User struct {
 	PasswordHash      string           `json:"-"`
 	Bio               *string          `json:"bio"`
 	ProfilePictureURL *string          `json:"profilePictureURL"`
+	IsModerator       bool             `json:"isModerator"`
+	DefaultLicense    string           `json:"defaultLicense"`
 	CreatedAt         pgtype.Timestamp `json:"createdAt"`
 	UpdatedAt         pgtype.Timestamp `json:"updatedAt"`
 }
 
+// PublicProfile is the projection of a user that is safe to show to anyone.
+type PublicProfile struct {
+	ID        uuid.UUID        `json:"id"`
+	Username  string           `json:"username"`
+	Bio       *string          `json:"bio"`
+	Avatar    *string          `json:"avatar"`
+	CreatedAt pgtype.Timestamp `json:"createdAt"`
+	Followers int64            `json:"followers"`
+	Following int64            `json:"following"`
+}
+
 type Image struct {
-	ID          uuid.UUID        `json:"id"`
-	UserID      uuid.UUID        `json:"userId"`
-	Title       string           `json:"title"`
-	Author      *string          `json:"author"`
-	Description *string          `json:"description"`
-	URL         string           `json:"url"`
-	CreatedAt   pgtype.Timestamp `json:"createdAt"`
-	UpdatedAt   pgtype.Timestamp `json:"updatedAt"`
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"userId"`
+	Title       string    `json:"title"`
+	Author      *string   `json:"author"`
+	Description *string   `json:"description"`
+	URL         string    `json:"url"`
+	Language    string    `json:"language"`
+	Visibility  string    `json:"visibility"`
+	// Status is one of draft, scheduled or published. PublishAt is when a
+	// scheduled post goes live.
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publishAt"`
+	License   License    `json:"license"`
+	SourceURL *string    `json:"sourceUrl"`
+	// Palette are the dominant colors of the image as hex codes, most
+	// dominant first. It is empty until they are extracted.
+	Palette []string `json:"palette"`
+	// Width and Height are in pixels, nil until read along with the palette
+	// or when the image cannot be decoded.
+	Width  *int     `json:"width"`
+	Height *int     `json:"height"`
+	Tags   []string `json:"tags"`
+	// ExplicitTags are the tags set by the owner, Tags also has the
+	// #hashtags of the description.
+	ExplicitTags []string         `json:"-"`
+	Mentions     []Mention        `json:"mentions"`
+	CreatedAt    pgtype.Timestamp `json:"createdAt"`
+	UpdatedAt    pgtype.Timestamp `json:"updatedAt"`
 }
 
 type Post struct {
-	Image    Image   `json:"image"`
-	Username string  `json:"username"`
-	Avatar   *string `json:"avatar"`
+	Image         Image            `json:"image"`
+	Username      string           `json:"username"`
+	Avatar        *string          `json:"avatar"`
+	Reactions     map[string]int64 `json:"reactions"`
+	CommentsCount int64            `json:"commentsCount"`
 }
 
 type Comment struct {
 	ID        uuid.UUID        `json:"id"`
 	UserID    uuid.UUID        `json:"userId"`
 	ImageID   uuid.UUID        `json:"imageId"`
+	ParentID  *uuid.UUID       `json:"parentId"`
 	Content   string           `json:"content"`
 	CreatedAt pgtype.Timestamp `json:"createdAt"`
 	UpdatedAt pgtype.Timestamp `json:"updatedAt"`
 
-	Username string  `json:"username"`
-	Avatar   *string `json:"avatar"`
+	Username  string           `json:"username"`
+	Avatar    *string          `json:"avatar"`
+	Reactions map[string]int64 `json:"reactions"`
+	Mentions  []Mention        `json:"mentions"`
+}
+
+// Mention is an @username span in a comment or an image description. Start
+// and End are offsets in characters, End is exclusive.
+type Mention struct {
+	UserID   uuid.UUID `json:"userId"`
+	Username string    `json:"username"`
+	Start    int       `json:"start"`
+	End      int       `json:"end"`
+}
+
+type SearchResult struct {
+	Post
+	Rank       float64          `json:"rank"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// SearchHighlights holds fragments of the matched fields with the matching
+// terms wrapped in <mark> tags.
+type SearchHighlights struct {
+	Title       string  `json:"title"`
+	Description *string `json:"description"`
+}
+
+type TagCount struct {
+	Name  string `json:"name"`
+	Posts int64  `json:"posts"`
+}
+
+type Album struct {
+	ID           uuid.UUID        `json:"id"`
+	UserID       uuid.UUID        `json:"userId"`
+	Title        string           `json:"title"`
+	Description  *string          `json:"description"`
+	CoverImageID *uuid.UUID       `json:"coverImageId"`
+	CreatedAt    pgtype.Timestamp `json:"createdAt"`
+	UpdatedAt    pgtype.Timestamp `json:"updatedAt"`
+
+	// CoverURL falls back to the first image of the album when no cover was
+	// chosen.
+	CoverURL    *string `json:"coverUrl"`
+	ImagesCount int64   `json:"imagesCount"`
+}
+
+// FollowUser is an account in a followers or following list.
+type FollowUser struct {
+	ID         uuid.UUID        `json:"id"`
+	Username   string           `json:"username"`
+	Avatar     *string          `json:"avatar"`
+	FollowedAt pgtype.Timestamp `json:"followedAt"`
+}
+
+type FollowCounts struct {
+	Followers int64 `json:"followers"`
+	Following int64 `json:"following"`
+}
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	ImageID   *uuid.UUID `json:"imageId"`
+	CommentID *uuid.UUID `json:"commentId"`
+	Read      bool       `json:"read"`
+	// Actors are the latest users who caused the notification, ActorsCount
+	// counts all of them.
+	Actors      []NotificationActor `json:"actors"`
+	ActorsCount int64               `json:"actorsCount"`
+	CreatedAt   pgtype.Timestamp    `json:"createdAt"`
+	UpdatedAt   pgtype.Timestamp    `json:"updatedAt"`
+}
+
+type NotificationActor struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	Avatar   *string   `json:"avatar"`
+}
+
+type Report struct {
+	ID         uuid.UUID        `json:"id"`
+	ReporterID uuid.UUID        `json:"reporterId"`
+	TargetType string           `json:"targetType"`
+	TargetID   uuid.UUID        `json:"targetId"`
+	Reason     string           `json:"reason"`
+	Details    *string          `json:"details"`
+	Status     string           `json:"status"`
+	Action     *string          `json:"action"`
+	ResolvedBy *uuid.UUID       `json:"resolvedBy"`
+	ResolvedAt pgtype.Timestamp `json:"resolvedAt"`
+	CreatedAt  pgtype.Timestamp `json:"createdAt"`
+}
+
+// License tells how an image may be reused. The flags say whether reusing it
+// requires attribution, is allowed commercially, allows derivative works and
+// requires those to be shared under the same terms.
+type License struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	URL         string `json:"url,omitempty"`
+	Attribution bool   `json:"attribution"`
+	Commercial  bool   `json:"commercial"`
+	Derivatives bool   `json:"derivatives"`
+	ShareAlike  bool   `json:"shareAlike"`
+}
+
+// Engagement counts what happened to a post, over a day or a whole period.
+type Engagement struct {
+	Views       int64 `json:"views"`
+	Impressions int64 `json:"impressions"`
+	Likes       int64 `json:"likes"`
+	Comments    int64 `json:"comments"`
+}
+
+type DailyEngagement struct {
+	// Day is formatted as YYYY-MM-DD, in UTC.
+	Day string `json:"day"`
+	Engagement
+}
+
+// ImageAnalytics is the engagement of a post over time. Days without any
+// activity are left out of Days.
+type ImageAnalytics struct {
+	ImageID uuid.UUID         `json:"imageId"`
+	Title   string            `json:"title"`
+	Totals  Engagement        `json:"totals"`
+	Days    []DailyEngagement `json:"days"`
+}
+
+// TrashedImage is a post in the trash of its owner, it is purged for good at
+// PurgeAt unless restored.
+type TrashedImage struct {
+	Image     Image            `json:"image"`
+	DeletedAt pgtype.Timestamp `json:"deletedAt"`
+	PurgeAt   time.Time        `json:"purgeAt"`
+}
+
+type TrashedComment struct {
+	Comment   Comment          `json:"comment"`
+	DeletedAt pgtype.Timestamp `json:"deletedAt"`
+	PurgeAt   time.Time        `json:"purgeAt"`
+}
+
+type Trash struct {
+	Posts    []TrashedImage   `json:"posts"`
+	Comments []TrashedComment `json:"comments"`
+}
+
+// ImageRevision is a version of the title, author, description and url of a
+// post. Revision 1 is the post as first published.
+type ImageRevision struct {
+	ImageID  uuid.UUID `json:"imageId"`
+	Revision int       `json:"revision"`
+	// EditorID is the user who made the change, nil once their account is
+	// deleted.
+	EditorID *uuid.UUID `json:"editorId"`
+	// RevertedFrom is the revision the post was reverted to, if any.
+	RevertedFrom *int             `json:"revertedFrom"`
+	Title        string           `json:"title"`
+	Author       *string          `json:"author"`
+	Description  *string          `json:"description"`
+	URL          string           `json:"url"`
+	CreatedAt    pgtype.Timestamp `json:"createdAt"`
+}
+
+// ImportResult is the outcome of a row of an import manifest, rows are
+// numbered from 1. Problems are the invalid fields of the row.
+type ImportResult struct {
+	Row      int               `json:"row"`
+	ImageID  *uuid.UUID        `json:"imageId,omitempty"`
+	Problems map[string]string `json:"problems,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// ImportJob is a bulk import of posts, Report grows as rows are processed.
+type ImportJob struct {
+	ID         uuid.UUID        `json:"id"`
+	UserID     uuid.UUID        `json:"userId"`
+	Format     string           `json:"format"`
+	Status     string           `json:"status"`
+	Total      int              `json:"total"`
+	Processed  int              `json:"processed"`
+	Succeeded  int              `json:"succeeded"`
+	Failed     int              `json:"failed"`
+	Report     []ImportResult   `json:"report"`
+	Error      *string          `json:"error"`
+	CreatedAt  pgtype.Timestamp `json:"createdAt"`
+	UpdatedAt  pgtype.Timestamp `json:"updatedAt"`
+	FinishedAt pgtype.Timestamp `json:"finishedAt"`
+}
+
+// ExportJob is the archive of a gallery being built in the background.
+// DownloadURL and ExpiresAt are set once it is completed.
+type ExportJob struct {
+	ID          uuid.UUID        `json:"id"`
+	UserID      uuid.UUID        `json:"userId"`
+	Status      string           `json:"status"`
+	Total       int              `json:"total"`
+	Processed   int              `json:"processed"`
+	Error       *string          `json:"error"`
+	DownloadURL *string          `json:"downloadUrl"`
+	ExpiresAt   *time.Time       `json:"expiresAt"`
+	CreatedAt   pgtype.Timestamp `json:"createdAt"`
+	UpdatedAt   pgtype.Timestamp `json:"updatedAt"`
+	FinishedAt  pgtype.Timestamp `json:"finishedAt"`
 }
@@ -0,0 +1,335 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrAlbumImagesMismatch = errors.New("image ids do not match the album images")
+
+type AlbumsRepository interface {
+	Create(ctx context.Context, album *models.Album) (uuid.UUID, error)
+	// FindByID and FindByUserID count the images of the albums and pick their
+	// covers among the ones viewerID can see. viewerID may be uuid.Nil.
+	FindByID(ctx context.Context, id, viewerID uuid.UUID) (*models.Album, error)
+	FindByUserID(ctx context.Context, userID, viewerID uuid.UUID) ([]models.Album, error)
+	Update(ctx context.Context, album *models.Album) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	AddImage(ctx context.Context, albumID, imageID uuid.UUID) error
+	RemoveImage(ctx context.Context, albumID, imageID uuid.UUID) error
+	// Reorder puts the album images viewerID can see in the order of
+	// imageIDs, which must hold exactly those images. The images hidden from
+	// viewerID keep their positions.
+	Reorder(ctx context.Context, albumID, viewerID uuid.UUID, imageIDs []uuid.UUID) error
+	// FindImages leaves out the images of the album owner viewerID cannot
+	// see and the images of other users that are not listed.
+	FindImages(ctx context.Context, albumID, viewerID uuid.UUID) ([]models.Post, error)
+}
+
+type PGXAlbumsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXAlbumsRepository(db *pgxpool.Pool) AlbumsRepository {
+	return &PGXAlbumsRepository{db}
+}
+
+// inAlbum is a condition matching the album images viewer can see, the
+// images of the album owner visible to viewer and the listed images of other
+// users. It needs albums joined.
+func inAlbum(viewer string) string {
+	return "(images.user_id = albums.user_id AND " + visibleTo(viewer) + " OR " + listed + ")"
+}
+
+// albumColumns lists the columns scanned by albumFields, in order. The count
+// and the cover only take the images viewer can see.
+func albumColumns(viewer string) string {
+	return `
+	albums.id,
+	albums.user_id,
+	albums.title,
+	albums.description,
+	albums.cover_image_id,
+	albums.created_at,
+	albums.updated_at,
+	COALESCE(
+		(
+			SELECT url FROM images
+			WHERE images.id = albums.cover_image_id AND ` + inAlbum(viewer) + `
+		),
+		(
+			SELECT images.url
+			FROM album_images
+			JOIN images ON images.id = album_images.image_id
+			WHERE album_images.album_id = albums.id AND ` + inAlbum(viewer) + `
+			ORDER BY album_images.position
+			LIMIT 1
+		)
+	) AS cover_url,
+	(
+		SELECT COUNT(*)
+		FROM album_images
+		JOIN images ON images.id = album_images.image_id
+		WHERE album_images.album_id = albums.id AND ` + inAlbum(viewer) + `
+	) AS images_count`
+}
+
+func albumFields(album *models.Album) []any {
+	return []any{
+		&album.ID,
+		&album.UserID,
+		&album.Title,
+		&album.Description,
+		&album.CoverImageID,
+		&album.CreatedAt,
+		&album.UpdatedAt,
+		&album.CoverURL,
+		&album.ImagesCount,
+	}
+}
+
+const createAlbumQuery = `
+	INSERT INTO albums (user_id, title, description, cover_image_id)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id;
+`
+
+func (r *PGXAlbumsRepository) Create(ctx context.Context, album *models.Album) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRow(
+		ctx,
+		createAlbumQuery,
+		album.UserID,
+		album.Title,
+		album.Description,
+		album.CoverImageID,
+	).Scan(&id)
+
+	return id, err
+}
+
+var findAlbumByIDQuery = "SELECT " + albumColumns("$2") + " FROM albums WHERE id = $1;"
+
+func (r *PGXAlbumsRepository) FindByID(
+	ctx context.Context,
+	id, viewerID uuid.UUID,
+) (*models.Album, error) {
+	var album models.Album
+	err := r.db.QueryRow(ctx, findAlbumByIDQuery, id, viewerID).Scan(albumFields(&album)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &album, nil
+}
+
+var findAlbumsByUserIDQuery = `
+	SELECT ` + albumColumns("$2") + `
+	FROM albums
+	WHERE user_id = $1
+	ORDER BY created_at DESC;
+`
+
+func (r *PGXAlbumsRepository) FindByUserID(
+	ctx context.Context,
+	userID, viewerID uuid.UUID,
+) ([]models.Album, error) {
+	rows, err := r.db.Query(ctx, findAlbumsByUserIDQuery, userID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var albums []models.Album
+	for rows.Next() {
+		var album models.Album
+
+		if err := rows.Scan(albumFields(&album)...); err != nil {
+			return nil, err
+		}
+
+		albums = append(albums, album)
+	}
+
+	return albums, rows.Err()
+}
+
+const updateAlbumQuery = `
+	UPDATE albums
+	SET "title" = $1, "description" = $2, "cover_image_id" = $3, "updated_at" = NOW()
+	WHERE id = $4;
+`
+
+func (r *PGXAlbumsRepository) Update(ctx context.Context, album *models.Album) error {
+	_, err := r.db.Exec(
+		ctx,
+		updateAlbumQuery,
+		album.Title,
+		album.Description,
+		album.CoverImageID,
+		album.ID,
+	)
+
+	return err
+}
+
+const deleteAlbumQuery = "DELETE FROM albums WHERE id = $1;"
+
+func (r *PGXAlbumsRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Exec(ctx, deleteAlbumQuery, id)
+	return err
+}
+
+// New images go to the end of the album, adding an image twice keeps its
+// position.
+const addAlbumImageQuery = `
+	INSERT INTO album_images (album_id, image_id, position)
+	SELECT $1, $2, COALESCE(MAX(position), -1) + 1
+	FROM album_images
+	WHERE album_id = $1
+	ON CONFLICT DO NOTHING;
+`
+
+func (r *PGXAlbumsRepository) AddImage(ctx context.Context, albumID, imageID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, addAlbumImageQuery, albumID, imageID)
+	return err
+}
+
+const removeAlbumImageQuery = `
+	DELETE FROM album_images
+	WHERE album_id = $1 AND image_id = $2;
+`
+
+func (r *PGXAlbumsRepository) RemoveImage(ctx context.Context, albumID, imageID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, removeAlbumImageQuery, albumID, imageID)
+	return err
+}
+
+var (
+	lockAlbumImagesQuery = `
+		SELECT album_images.image_id, ` + inAlbum("$2") + ` AS visible
+		FROM album_images
+		JOIN albums ON albums.id = album_images.album_id
+		JOIN images ON images.id = album_images.image_id
+		WHERE album_images.album_id = $1
+		ORDER BY album_images.position
+		FOR UPDATE OF album_images;
+	`
+	reorderAlbumImagesQuery = `
+		UPDATE album_images
+		SET position = ordered.position - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS ordered(image_id, position)
+		WHERE album_images.album_id = $1 AND album_images.image_id = ordered.image_id;
+	`
+)
+
+func (r *PGXAlbumsRepository) Reorder(
+	ctx context.Context,
+	albumID, viewerID uuid.UUID,
+	imageIDs []uuid.UUID,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, lockAlbumImagesQuery, albumID, viewerID)
+	if err != nil {
+		return err
+	}
+
+	type albumImage struct {
+		id      uuid.UUID
+		visible bool
+	}
+
+	var current []albumImage
+	for rows.Next() {
+		var image albumImage
+		if err := rows.Scan(&image.id, &image.visible); err != nil {
+			rows.Close()
+			return err
+		}
+		current = append(current, image)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	visible := make(map[uuid.UUID]bool)
+	for _, image := range current {
+		if image.visible {
+			visible[image.id] = true
+		}
+	}
+
+	if len(visible) != len(imageIDs) {
+		return ErrAlbumImagesMismatch
+	}
+
+	for _, id := range imageIDs {
+		if !visible[id] {
+			return ErrAlbumImagesMismatch
+		}
+		delete(visible, id)
+	}
+
+	// The visible images take the slots of the visible images in the new
+	// order, the hidden ones stay where they are.
+	ordered := make([]uuid.UUID, 0, len(current))
+	next := 0
+	for _, image := range current {
+		if image.visible {
+			ordered = append(ordered, imageIDs[next])
+			next++
+			continue
+		}
+		ordered = append(ordered, image.id)
+	}
+
+	if _, err := tx.Exec(ctx, reorderAlbumImagesQuery, albumID, ordered); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+var findAlbumImagesQuery = "SELECT " + postColumns + `
+	FROM album_images
+	JOIN albums ON albums.id = album_images.album_id
+	JOIN images ON images.id = album_images.image_id` + postJoins + `
+	WHERE album_images.album_id = $1 AND ` + inAlbum("$2") + `
+	ORDER BY album_images.position;
+`
+
+func (r *PGXAlbumsRepository) FindImages(
+	ctx context.Context,
+	albumID, viewerID uuid.UUID,
+) ([]models.Post, error) {
+	rows, err := r.db.Query(ctx, findAlbumImagesQuery, albumID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+
+		if err := rows.Scan(postFields(&post)...); err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
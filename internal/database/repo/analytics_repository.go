@@ -0,0 +1,193 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ViewCount is how many views and impressions a post got on Day, which is
+// truncated to midnight UTC.
+type ViewCount struct {
+	ImageID     uuid.UUID
+	Day         time.Time
+	Views       int64
+	Impressions int64
+}
+
+type AnalyticsRepository interface {
+	// AddViews adds the counts to the rollups, counts of deleted posts are
+	// dropped.
+	AddViews(ctx context.Context, counts []ViewCount) error
+	// RollUp recounts the likes and comments of every day since the given one.
+	RollUp(ctx context.Context, since time.Time) error
+	// FindByUserID returns the rollups of every post of the user since the
+	// given day, newest posts first.
+	FindByUserID(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.ImageAnalytics, error)
+}
+
+type PGXAnalyticsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXAnalyticsRepository(db *pgxpool.Pool) AnalyticsRepository {
+	return &PGXAnalyticsRepository{db}
+}
+
+const addViewsQuery = `
+	INSERT INTO image_daily_stats (image_id, day, views, impressions)
+	SELECT counts.image_id, counts.day, counts.views, counts.impressions
+	FROM unnest($1::uuid[], $2::date[], $3::bigint[], $4::bigint[])
+		AS counts (image_id, day, views, impressions)
+	JOIN images ON images.id = counts.image_id
+	ON CONFLICT (image_id, day) DO UPDATE SET
+		views = image_daily_stats.views + EXCLUDED.views,
+		impressions = image_daily_stats.impressions + EXCLUDED.impressions;
+`
+
+func (r *PGXAnalyticsRepository) AddViews(ctx context.Context, counts []ViewCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	imageIDs := make([]uuid.UUID, len(counts))
+	days := make([]time.Time, len(counts))
+	views := make([]int64, len(counts))
+	impressions := make([]int64, len(counts))
+	for i, count := range counts {
+		imageIDs[i] = count.ImageID
+		days[i] = count.Day
+		views[i] = count.Views
+		impressions[i] = count.Impressions
+	}
+
+	_, err := r.db.Exec(ctx, addViewsQuery, imageIDs, days, views, impressions)
+	return err
+}
+
+// Likes and comments removed since are not counted anymore, so the days are
+// reset before being recounted.
+const resetActivityQuery = `
+	UPDATE image_daily_stats SET likes = 0, comments = 0
+	WHERE day >= $1::date AND (likes <> 0 OR comments <> 0);
+`
+
+// utcDay is the UTC day the timestamp in column falls on. Timestamps are
+// stored in the time zone of the database while views are counted by UTC day.
+func utcDay(column string) string {
+	return "(" + column + " AT TIME ZONE current_setting('TimeZone') AT TIME ZONE 'UTC')::date"
+}
+
+var rollUpActivityQuery = `
+	INSERT INTO image_daily_stats (image_id, day, likes, comments)
+	SELECT image_id, day, SUM(likes), SUM(comments)
+	FROM (
+		SELECT image_id, ` + utcDay("created_at") + ` AS day, COUNT(*) AS likes, 0 AS comments
+		FROM image_reactions
+		WHERE ` + utcDay("created_at") + ` >= $1::date
+		GROUP BY image_id, day
+		UNION ALL
+		SELECT image_id, ` + utcDay("created_at") + ` AS day, 0 AS likes, COUNT(*) AS comments
+		FROM comments
+		WHERE ` + utcDay("created_at") + ` >= $1::date AND deleted_at IS NULL
+		GROUP BY image_id, day
+	) AS activity
+	GROUP BY image_id, day
+	ON CONFLICT (image_id, day) DO UPDATE SET
+		likes = EXCLUDED.likes,
+		comments = EXCLUDED.comments;
+`
+
+func (r *PGXAnalyticsRepository) RollUp(ctx context.Context, since time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, resetActivityQuery, since); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, rollUpActivityQuery, since); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+const findAnalyticsByUserIDQuery = `
+	SELECT
+		images.id,
+		images.title,
+		to_char(stats.day, 'YYYY-MM-DD'),
+		COALESCE(stats.views, 0),
+		COALESCE(stats.impressions, 0),
+		COALESCE(stats.likes, 0),
+		COALESCE(stats.comments, 0)
+	FROM images
+	LEFT JOIN image_daily_stats AS stats
+		ON stats.image_id = images.id AND stats.day >= $2::date
+	WHERE images.user_id = $1 AND images.deleted_at IS NULL
+	ORDER BY images.created_at DESC, images.id, stats.day;
+`
+
+func (r *PGXAnalyticsRepository) FindByUserID(
+	ctx context.Context,
+	userID uuid.UUID,
+	since time.Time,
+) ([]models.ImageAnalytics, error) {
+	rows, err := r.db.Query(ctx, findAnalyticsByUserIDQuery, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	analytics := []models.ImageAnalytics{}
+	for rows.Next() {
+		var (
+			imageID uuid.UUID
+			title   string
+			day     *string
+			daily   models.Engagement
+		)
+
+		err := rows.Scan(
+			&imageID,
+			&title,
+			&day,
+			&daily.Views,
+			&daily.Impressions,
+			&daily.Likes,
+			&daily.Comments,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Rows come grouped by image, a new one starts its own entry.
+		if len(analytics) == 0 || analytics[len(analytics)-1].ImageID != imageID {
+			analytics = append(analytics, models.ImageAnalytics{
+				ImageID: imageID,
+				Title:   title,
+				Days:    []models.DailyEngagement{},
+			})
+		}
+
+		if day == nil {
+			continue
+		}
+
+		image := &analytics[len(analytics)-1]
+		image.Days = append(image.Days, models.DailyEngagement{Day: *day, Engagement: daily})
+		image.Totals.Views += daily.Views
+		image.Totals.Impressions += daily.Impressions
+		image.Totals.Likes += daily.Likes
+		image.Totals.Comments += daily.Comments
+	}
+
+	return analytics, rows.Err()
+}
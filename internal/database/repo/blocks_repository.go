@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BlocksRepository interface {
+	// Block also removes the follows between both users.
+	Block(ctx context.Context, blockerID, blockedID uuid.UUID) error
+	Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) error
+	Mute(ctx context.Context, muterID, mutedID uuid.UUID) error
+	Unmute(ctx context.Context, muterID, mutedID uuid.UUID) error
+	IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error)
+}
+
+type PGXBlocksRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXBlocksRepository(db *pgxpool.Pool) BlocksRepository {
+	return &PGXBlocksRepository{db}
+}
+
+// silencedBy is a condition matching rows whose author, in column, the viewer
+// muted or blocked. A nil viewer matches nothing.
+func silencedBy(column, viewer string) string {
+	return column + ` IN (
+		SELECT muted_id FROM mutes WHERE muter_id = ` + viewer + `
+		UNION ALL
+		SELECT blocked_id FROM blocks WHERE blocker_id = ` + viewer + `
+	)`
+}
+
+const (
+	blockQuery = `
+	INSERT INTO blocks (blocker_id, blocked_id)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING;
+`
+	removeBlockedFollowsQuery = `
+	DELETE FROM follows
+	WHERE (follower_id = $1 AND followee_id = $2)
+		OR (follower_id = $2 AND followee_id = $1);
+`
+)
+
+func (r *PGXBlocksRepository) Block(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, blockQuery, blockerID, blockedID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, removeBlockedFollowsQuery, blockerID, blockedID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+const unblockQuery = `
+	DELETE FROM blocks
+	WHERE blocker_id = $1 AND blocked_id = $2;
+`
+
+func (r *PGXBlocksRepository) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, unblockQuery, blockerID, blockedID)
+	return err
+}
+
+const muteQuery = `
+	INSERT INTO mutes (muter_id, muted_id)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING;
+`
+
+func (r *PGXBlocksRepository) Mute(ctx context.Context, muterID, mutedID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, muteQuery, muterID, mutedID)
+	return err
+}
+
+const unmuteQuery = `
+	DELETE FROM mutes
+	WHERE muter_id = $1 AND muted_id = $2;
+`
+
+func (r *PGXBlocksRepository) Unmute(ctx context.Context, muterID, mutedID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, unmuteQuery, muterID, mutedID)
+	return err
+}
+
+const isBlockedQuery = `
+	SELECT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE blocker_id = $1 AND blocked_id = $2
+	);
+`
+
+func (r *PGXBlocksRepository) IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	var blocked bool
+	err := r.db.QueryRow(ctx, isBlockedQuery, blockerID, blockedID).Scan(&blocked)
+	return blocked, err
+}
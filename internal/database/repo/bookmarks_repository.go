@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BookmarksRepository interface {
+	Add(ctx context.Context, userID, imageID uuid.UUID) error
+	Remove(ctx context.Context, userID, imageID uuid.UUID) error
+	// FindByUserID returns a page of the bookmarked posts, most recently saved
+	// first, and the cursor to the next one.
+	FindByUserID(ctx context.Context, userID uuid.UUID, after *Cursor) ([]models.Post, *Cursor, error)
+}
+
+type PGXBookmarksRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXBookmarksRepository(db *pgxpool.Pool) BookmarksRepository {
+	return &PGXBookmarksRepository{db}
+}
+
+const addBookmarkQuery = `
+	INSERT INTO bookmarks (user_id, image_id)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING;
+`
+
+func (r *PGXBookmarksRepository) Add(ctx context.Context, userID, imageID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, addBookmarkQuery, userID, imageID)
+	return err
+}
+
+const removeBookmarkQuery = `
+	DELETE FROM bookmarks
+	WHERE user_id = $1 AND image_id = $2;
+`
+
+func (r *PGXBookmarksRepository) Remove(ctx context.Context, userID, imageID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, removeBookmarkQuery, userID, imageID)
+	return err
+}
+
+func (r *PGXBookmarksRepository) FindByUserID(
+	ctx context.Context,
+	userID uuid.UUID,
+	after *Cursor,
+) ([]models.Post, *Cursor, error) {
+	var b queryBuilder
+
+	viewer := b.arg(userID)
+	b.where("bookmarks.user_id = " + viewer)
+	// Posts made private after being saved are no longer shown.
+	b.where(visibleTo(viewer))
+	if after != nil {
+		b.where("(bookmarks.created_at, bookmarks.image_id) < (" +
+			b.arg(after.CreatedAt) + ", " + b.arg(after.ID) + ")")
+	}
+
+	sql := "SELECT " + postColumns + `,
+		bookmarks.created_at
+	FROM bookmarks
+	JOIN images ON images.id = bookmarks.image_id` + postJoins +
+		b.whereClause() + `
+	ORDER BY bookmarks.created_at DESC, bookmarks.image_id DESC
+	LIMIT ` + b.arg(ITEMS_PER_PAGE)
+
+	rows, err := r.db.Query(ctx, sql, b.args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var (
+		posts        []models.Post
+		bookmarkedAt time.Time
+	)
+
+	for rows.Next() {
+		var post models.Post
+
+		if err := rows.Scan(append(postFields(&post), &bookmarkedAt)...); err != nil {
+			return nil, nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(posts) < ITEMS_PER_PAGE {
+		return posts, nil, nil
+	}
+
+	return posts, &Cursor{
+		Kind:      CursorBookmarks,
+		CreatedAt: bookmarkedAt,
+		ID:        posts[len(posts)-1].Image.ID,
+	}, nil
+}
@@ -11,10 +11,26 @@ import (
 type CommentsRepository interface {
 	Create(ctx context.Context, comment *models.Comment) (uuid.UUID, error)
 	FindByID(ctx context.Context, commentID uuid.UUID) (*models.Comment, error)
+	// FindVisible returns the comment unless it is hidden by moderators or
+	// written by an account the viewer muted or blocked. viewerID may be
+	// uuid.Nil.
+	FindVisible(ctx context.Context, commentID, viewerID uuid.UUID) (*models.Comment, error)
 
+	// FindByImageID leaves out comments hidden by moderators and those of the
+	// accounts the viewer muted or blocked. viewerID may be uuid.Nil.
 	FindByImageID(
 		ctx context.Context,
 		imageID uuid.UUID,
+		viewerID uuid.UUID,
+	) ([]models.Comment, error)
+	// FindAfter returns up to limit comments on the image created after the
+	// cursor, oldest first, leaving out the same comments as FindByImageID.
+	FindAfter(
+		ctx context.Context,
+		imageID uuid.UUID,
+		viewerID uuid.UUID,
+		after *Cursor,
+		limit int,
 	) ([]models.Comment, error)
 }
 
@@ -26,21 +42,88 @@ func NewPGXCommentsRepository(pool *pgxpool.Pool) CommentsRepository {
 	return &PGXCommentsRepository{pool}
 }
 
-const findCommentByIDQuery = "SELECT * FROM comments WHERE id = $1;"
-
-func (r *PGXCommentsRepository) FindByID(
-	ctx context.Context,
-	commentID uuid.UUID,
-) (*models.Comment, error) {
-	var comment models.Comment
-	err := r.pool.QueryRow(ctx, findCommentByIDQuery, commentID).Scan(
+// commentColumns lists the columns scanned by commentFields, in order. Queries
+// selecting them must join users on the comment author.
+const commentColumns = `
+	comments.id,
+	comments.user_id,
+	comments.image_id,
+	comments.parent_id,
+	comments.content,
+	comments.created_at,
+	comments.updated_at,
+	users.username,
+	users.profile_picture_url,
+	COALESCE((
+		SELECT jsonb_object_agg(counts.emoji, counts.total)
+		FROM (
+			SELECT emoji, COUNT(*) AS total
+			FROM comment_reactions
+			WHERE comment_reactions.comment_id = comments.id
+			GROUP BY emoji
+		) AS counts
+	), '{}'::jsonb) AS reactions,
+	COALESCE((
+		SELECT jsonb_agg(jsonb_build_object(
+			'userId', mentioned.id,
+			'username', mentioned.username,
+			'start', comment_mentions.start_offset,
+			'end', comment_mentions.end_offset
+		) ORDER BY comment_mentions.start_offset)
+		FROM comment_mentions
+		JOIN users AS mentioned ON mentioned.id = comment_mentions.user_id
+		WHERE comment_mentions.comment_id = comments.id
+	), '[]'::jsonb) AS mentions`
+
+func commentFields(comment *models.Comment) []any {
+	return []any{
 		&comment.ID,
 		&comment.UserID,
 		&comment.ImageID,
+		&comment.ParentID,
 		&comment.Content,
 		&comment.CreatedAt,
 		&comment.UpdatedAt,
-	)
+		&comment.Username,
+		&comment.Avatar,
+		&comment.Reactions,
+		&comment.Mentions,
+	}
+}
+
+const findCommentByIDQuery = "SELECT " + commentColumns + `
+	FROM comments
+	JOIN users ON comments.user_id = users.id
+	WHERE comments.id = $1 AND comments.deleted_at IS NULL;
+`
+
+func (r *PGXCommentsRepository) FindByID(
+	ctx context.Context,
+	commentID uuid.UUID,
+) (*models.Comment, error) {
+	var comment models.Comment
+	err := r.pool.QueryRow(ctx, findCommentByIDQuery, commentID).Scan(commentFields(&comment)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+var findVisibleCommentQuery = "SELECT " + commentColumns + `
+	FROM comments
+	JOIN users ON comments.user_id = users.id
+	WHERE comments.id = $1
+		AND comments.hidden_at IS NULL
+		AND comments.deleted_at IS NULL
+		AND NOT ` + silencedBy("comments.user_id", "$2") + ";"
+
+func (r *PGXCommentsRepository) FindVisible(
+	ctx context.Context,
+	commentID, viewerID uuid.UUID,
+) (*models.Comment, error) {
+	var comment models.Comment
+	err := r.pool.QueryRow(ctx, findVisibleCommentQuery, commentID, viewerID).Scan(commentFields(&comment)...)
 	if err != nil {
 		return nil, err
 	}
@@ -49,8 +132,8 @@ func (r *PGXCommentsRepository) FindByID(
 }
 
 const createCommentQuery = `
-	INSERT INTO comments (user_id, image_id, content)
-	VALUES ($1, $2, $3)
+	INSERT INTO comments (user_id, image_id, parent_id, content)
+	VALUES ($1, $2, $3, $4)
 	RETURNING id
 `
 
@@ -58,39 +141,49 @@ func (r *PGXCommentsRepository) Create(
 	ctx context.Context,
 	comment *models.Comment,
 ) (uuid.UUID, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	var commentID uuid.UUID
-	err := r.pool.QueryRow(
+	err = tx.QueryRow(
 		ctx,
 		createCommentQuery,
 		comment.UserID,
 		comment.ImageID,
+		comment.ParentID,
 		comment.Content,
 	).Scan(&commentID)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
-	return commentID, nil
+	err = setMentions(ctx, tx, "comment_mentions", "comment_id", commentID, comment.Mentions)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return commentID, tx.Commit(ctx)
 }
 
-const findCommentsByImageIDQuery = `
-	SELECT
-		comments.id,
-		comments.user_id,
-		comments.image_id,
-		comments.content,
-		users.username,
-		users.profile_picture_url
+var findCommentsByImageIDQuery = "SELECT " + commentColumns + `
 	FROM comments
 	JOIN users ON comments.user_id = users.id
-	WHERE comments.image_id = $1;
+	WHERE comments.image_id = $1
+		AND comments.hidden_at IS NULL
+		AND comments.deleted_at IS NULL
+		AND NOT ` + silencedBy("comments.user_id", "$2") + `
+	ORDER BY comments.created_at, comments.id;
 `
 
 func (r *PGXCommentsRepository) FindByImageID(
 	ctx context.Context,
 	imageID uuid.UUID,
+	viewerID uuid.UUID,
 ) ([]models.Comment, error) {
-	rows, err := r.pool.Query(ctx, findCommentsByImageIDQuery, imageID)
+	rows, err := r.pool.Query(ctx, findCommentsByImageIDQuery, imageID, viewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -100,14 +193,7 @@ func (r *PGXCommentsRepository) FindByImageID(
 	for rows.Next() {
 		var comment models.Comment
 
-		err := rows.Scan(
-			&comment.ID,
-			&comment.UserID,
-			&comment.ImageID,
-			&comment.Content,
-			&comment.Username,
-			&comment.Avatar,
-		)
+		err := rows.Scan(commentFields(&comment)...)
 		if err != nil {
 			return nil, err
 		}
@@ -115,5 +201,52 @@ func (r *PGXCommentsRepository) FindByImageID(
 		comments = append(comments, comment)
 	}
 
-	return comments, nil
+	return comments, rows.Err()
+}
+
+var findCommentsAfterQuery = "SELECT " + commentColumns + `
+	FROM comments
+	JOIN users ON comments.user_id = users.id
+	WHERE comments.image_id = $1
+		AND comments.hidden_at IS NULL
+		AND comments.deleted_at IS NULL
+		AND NOT ` + silencedBy("comments.user_id", "$5") + `
+		AND (comments.created_at, comments.id) > ($2, $3)
+	ORDER BY comments.created_at, comments.id
+	LIMIT $4;
+`
+
+func (r *PGXCommentsRepository) FindAfter(
+	ctx context.Context,
+	imageID uuid.UUID,
+	viewerID uuid.UUID,
+	after *Cursor,
+	limit int,
+) ([]models.Comment, error) {
+	rows, err := r.pool.Query(
+		ctx,
+		findCommentsAfterQuery,
+		imageID,
+		after.CreatedAt,
+		after.ID,
+		limit,
+		viewerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var comment models.Comment
+
+		if err := rows.Scan(commentFields(&comment)...); err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
 }
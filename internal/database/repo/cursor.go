@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorKind tags a cursor with the list it paginates, so that a cursor of
+// one list is rejected by the others. Feed cursors are tagged with their sort.
+type CursorKind string
+
+const (
+	CursorFollowers     CursorKind = "followers"
+	CursorFollowing     CursorKind = "following"
+	CursorNotifications CursorKind = "notified"
+	CursorBookmarks     CursorKind = "bookmarked"
+	CursorSearch        CursorKind = "relevance"
+	CursorComments      CursorKind = "commented"
+	CursorReports       CursorKind = "reported"
+)
+
+// Cursor marks the last row of a page for keyset pagination. Rows are always
+// ordered by (score, created_at, id), where score depends on the list and is
+// zero when it is ordered by time alone.
+type Cursor struct {
+	Kind      CursorKind
+	Score     float64
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// String encodes the cursor into an opaque token safe to use in URLs.
+func (c Cursor) String() string {
+	raw := strings.Join([]string{
+		string(c.Kind),
+		strconv.FormatFloat(c.Score, 'g', -1, 64),
+		strconv.FormatInt(c.CreatedAt.UnixNano(), 10),
+		c.ID.String(),
+	}, ":")
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 {
+		return nil, ErrInvalidCursor
+	}
+
+	score, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	id, err := uuid.Parse(parts[3])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{
+		Kind:      CursorKind(parts[0]),
+		Score:     score,
+		CreatedAt: time.Unix(0, nanos).UTC(),
+		ID:        id,
+	}, nil
+}
@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ExportsRepository interface {
+	// Create queues an export of the user, unless one is already pending or
+	// running, which is returned instead.
+	Create(ctx context.Context, userID uuid.UUID, total int) (*models.ExportJob, error)
+	FindByID(ctx context.Context, jobID uuid.UUID) (*models.ExportJob, error)
+	// Claim marks the oldest pending job as running and returns it, along
+	// with running jobs not updated for longer than stale, which were left
+	// behind by a stopped server. It returns pgx.ErrNoRows when there is none.
+	Claim(ctx context.Context, stale time.Duration) (*models.ExportJob, error)
+	SetProgress(ctx context.Context, jobID uuid.UUID, processed int) error
+	// Finish completes the job, or fails it when failure is set.
+	Finish(ctx context.Context, jobID uuid.UUID, failure *string) error
+	// DeleteFinishedBefore deletes the jobs finished before the given time
+	// and returns their ids.
+	DeleteFinishedBefore(ctx context.Context, before time.Time) ([]uuid.UUID, error)
+}
+
+type PGXExportsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXExportsRepository(db *pgxpool.Pool) ExportsRepository {
+	return &PGXExportsRepository{db}
+}
+
+const exportJobColumns = `
+	id,
+	user_id,
+	status,
+	total,
+	processed,
+	error,
+	created_at,
+	updated_at,
+	finished_at`
+
+func exportJobFields(job *models.ExportJob) []any {
+	return []any{
+		&job.ID,
+		&job.UserID,
+		&job.Status,
+		&job.Total,
+		&job.Processed,
+		&job.Error,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.FinishedAt,
+	}
+}
+
+const (
+	createExportJobQuery = `
+		INSERT INTO export_jobs (user_id, total)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) WHERE status IN ('pending', 'running') DO NOTHING
+		RETURNING ` + exportJobColumns + `;
+	`
+	findUnfinishedExportJobQuery = "SELECT " + exportJobColumns + `
+		FROM export_jobs
+		WHERE user_id = $1 AND status IN ('pending', 'running');
+	`
+)
+
+func (r *PGXExportsRepository) Create(
+	ctx context.Context,
+	userID uuid.UUID,
+	total int,
+) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.db.QueryRow(ctx, createExportJobQuery, userID, total).Scan(exportJobFields(&job)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = r.db.QueryRow(ctx, findUnfinishedExportJobQuery, userID).Scan(exportJobFields(&job)...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+const findExportJobQuery = "SELECT " + exportJobColumns + " FROM export_jobs WHERE id = $1;"
+
+func (r *PGXExportsRepository) FindByID(ctx context.Context, jobID uuid.UUID) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.db.QueryRow(ctx, findExportJobQuery, jobID).Scan(exportJobFields(&job)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+const claimExportJobQuery = `
+	UPDATE export_jobs SET status = 'running', processed = 0, updated_at = NOW()
+	WHERE id = (
+		SELECT id FROM export_jobs
+		WHERE status = 'pending'
+			OR (status = 'running' AND updated_at < NOW() - make_interval(secs => $1))
+		ORDER BY created_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + exportJobColumns + `;
+`
+
+func (r *PGXExportsRepository) Claim(ctx context.Context, stale time.Duration) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.db.QueryRow(ctx, claimExportJobQuery, stale.Seconds()).Scan(exportJobFields(&job)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+const setExportProgressQuery = `
+	UPDATE export_jobs SET processed = $2, updated_at = NOW()
+	WHERE id = $1;
+`
+
+func (r *PGXExportsRepository) SetProgress(ctx context.Context, jobID uuid.UUID, processed int) error {
+	_, err := r.db.Exec(ctx, setExportProgressQuery, jobID, processed)
+	return err
+}
+
+const finishExportJobQuery = `
+	UPDATE export_jobs SET
+		status = CASE WHEN $2::text IS NULL THEN 'completed' ELSE 'failed' END,
+		error = $2,
+		updated_at = NOW(),
+		finished_at = NOW()
+	WHERE id = $1;
+`
+
+func (r *PGXExportsRepository) Finish(ctx context.Context, jobID uuid.UUID, failure *string) error {
+	_, err := r.db.Exec(ctx, finishExportJobQuery, jobID, failure)
+	return err
+}
+
+const deleteFinishedExportJobsQuery = `
+	DELETE FROM export_jobs
+	WHERE finished_at < $1
+	RETURNING id;
+`
+
+func (r *PGXExportsRepository) DeleteFinishedBefore(
+	ctx context.Context,
+	before time.Time,
+) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx, deleteFinishedExportJobsQuery, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobIDs []uuid.UUID
+	for rows.Next() {
+		var jobID uuid.UUID
+		if err := rows.Scan(&jobID); err != nil {
+			return nil, err
+		}
+
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	return jobIDs, rows.Err()
+}
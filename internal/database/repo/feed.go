@@ -0,0 +1,306 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type FeedSort string
+
+const (
+	SortNewest   FeedSort = "newest"
+	SortTop      FeedSort = "top"
+	SortTrending FeedSort = "trending"
+)
+
+// FeedQuery describes a page of posts. When After is set the page is fetched
+// with keyset pagination, otherwise Page is used as an offset.
+type FeedQuery struct {
+	Sort FeedSort
+	// Within restricts the feed to posts created in the last given duration,
+	// zero means no restriction.
+	Within time.Duration
+	// Tag restricts the feed to posts tagged with it.
+	Tag string
+	// Licenses restricts the feed to posts under any of them, empty means no
+	// restriction.
+	Licenses []string
+	// Color restricts the feed to posts with a dominant color close to it,
+	// nil means no restriction.
+	Color *Lab
+	// UserID restricts the feed to posts of the user, uuid.Nil means no
+	// restriction.
+	UserID uuid.UUID
+	// FollowedBy restricts the feed to posts of the accounts the user follows,
+	// uuid.Nil means no restriction.
+	FollowedBy uuid.UUID
+	// ViewerID leaves out the posts of the accounts the user muted or
+	// blocked, uuid.Nil means no restriction.
+	ViewerID uuid.UUID
+	After    *Cursor
+	Page     uint64
+}
+
+type feedOrder struct {
+	// score is selected with every row so the next cursor can be built from
+	// the last one.
+	score string
+	// columns are the keyset, every one of them sorted descending.
+	columns []string
+}
+
+var feedOrders = map[FeedSort]feedOrder{
+	SortNewest: {
+		score:   "0::float8",
+		columns: []string{"images.created_at", "images.id"},
+	},
+	SortTop: {
+		score:   "image_stats.reactions_count::float8",
+		columns: []string{"image_stats.reactions_count", "image_stats.created_at", "image_stats.image_id"},
+	},
+	SortTrending: {
+		score:   "image_stats.hot_score",
+		columns: []string{"image_stats.hot_score", "image_stats.created_at", "image_stats.image_id"},
+	},
+}
+
+// Valid reports whether s is one of the sorts feeds can be ordered by.
+func (s FeedSort) Valid() bool {
+	_, ok := feedOrders[s]
+	return ok
+}
+
+// CursorKind is the kind of the cursors of feeds ordered by s.
+func (s FeedSort) CursorKind() CursorKind {
+	return CursorKind(s)
+}
+
+// postColumns lists the columns scanned by postFields, in order. Queries
+// selecting them must join postJoins.
+const postColumns = imageColumns + `,
+	users.username,
+	users.profile_picture_url,
+	COALESCE((
+		SELECT jsonb_object_agg(counts.emoji, counts.total)
+		FROM (
+			SELECT emoji, COUNT(*) AS total
+			FROM image_reactions
+			WHERE image_reactions.image_id = images.id
+			GROUP BY emoji
+		) AS counts
+	), '{}'::jsonb) AS reactions,
+	image_stats.comments_count`
+
+const postJoins = `
+	JOIN users ON images.user_id = users.id
+	JOIN image_stats ON image_stats.image_id = images.id`
+
+func postFields(post *models.Post) []any {
+	return append(
+		imageFields(&post.Image),
+		&post.Username,
+		&post.Avatar,
+		&post.Reactions,
+		&post.CommentsCount,
+	)
+}
+
+const selectPostsQuery = "SELECT " + postColumns + ",\n\t%s AS sort_score\n\tFROM images" + postJoins
+
+func buildFeedQuery(query FeedQuery) (string, []any) {
+	order := feedOrders[query.Sort]
+	var b queryBuilder
+
+	// Owners see every one of their published posts when listing them.
+	if query.UserID != uuid.Nil && query.UserID == query.ViewerID {
+		b.where("images.hidden_at IS NULL AND " + published)
+	} else {
+		b.where(listed)
+	}
+
+	if query.Within > 0 {
+		b.where("image_stats.created_at >= LOCALTIMESTAMP - " + b.arg(query.Within) + "::interval")
+	}
+
+	if query.Tag != "" {
+		b.where(`EXISTS (
+		SELECT 1 FROM image_tags
+		JOIN tags ON tags.id = image_tags.tag_id
+		WHERE image_tags.image_id = images.id AND tags.name = ` + b.arg(query.Tag) + `
+	)`)
+	}
+
+	if query.UserID != uuid.Nil {
+		b.where("images.user_id = " + b.arg(query.UserID))
+	}
+
+	if len(query.Licenses) > 0 {
+		b.where("images.license = ANY(" + b.arg(query.Licenses) + ")")
+	}
+
+	if query.Color != nil {
+		b.where(closeToColor(&b, *query.Color))
+	}
+
+	if query.FollowedBy != uuid.Nil {
+		b.where(followedCondition(&b, query))
+	}
+
+	if query.ViewerID != uuid.Nil {
+		b.where("NOT " + silencedBy("images.user_id", b.arg(query.ViewerID)))
+	}
+
+	if query.After != nil {
+		keys := []string{b.arg(query.After.CreatedAt), b.arg(query.After.ID)}
+		if query.Sort != SortNewest {
+			keys = append([]string{b.arg(query.After.Score)}, keys...)
+		}
+
+		b.where(fmt.Sprintf(
+			"(%s) < (%s)",
+			strings.Join(order.columns, ", "),
+			strings.Join(keys, ", "),
+		))
+	}
+
+	orderBy := make([]string, 0, len(order.columns))
+	for _, column := range order.columns {
+		orderBy = append(orderBy, column+" DESC")
+	}
+
+	sql := fmt.Sprintf(selectPostsQuery, order.score) +
+		b.whereClause() +
+		"\n\tORDER BY " + strings.Join(orderBy, ", ") +
+		"\n\tLIMIT " + b.arg(ITEMS_PER_PAGE)
+
+	if query.After == nil && query.Page > 1 {
+		sql += "\n\tOFFSET " + b.arg((query.Page-1)*ITEMS_PER_PAGE)
+	}
+
+	return sql, b.args
+}
+
+// maxColorDistance is how far apart in Lab two colors can be to be considered
+// alike, around where most people start telling them apart at a glance.
+const maxColorDistance = 20
+
+// closeToColor matches the images with a dominant color close to color.
+func closeToColor(b *queryBuilder, color Lab) string {
+	return `EXISTS (
+		SELECT 1 FROM image_colors
+		WHERE image_colors.image_id = images.id
+			AND (image_colors.l - ` + b.arg(color.L) + `) ^ 2
+				+ (image_colors.a - ` + b.arg(color.A) + `) ^ 2
+				+ (image_colors.b - ` + b.arg(color.B) + `) ^ 2
+				<= ` + b.arg(maxColorDistance*maxColorDistance) + `
+	)`
+}
+
+// followedCondition restricts the feed to the posts of followed accounts.
+// When sorting by newest, at most one page worth of posts is read from each
+// account through images_user_id_created_at_idx, so the cost depends on the
+// number of followed accounts and not on how many posts they have.
+func followedCondition(b *queryBuilder, query FeedQuery) string {
+	follower := b.arg(query.FollowedBy)
+	if query.Sort != SortNewest {
+		return "images.user_id IN (SELECT followee_id FROM follows WHERE follower_id = " + follower + ")"
+	}
+
+	var after string
+	if query.After != nil {
+		after = "\n\t\t\tAND (followed.created_at, followed.id) < (" +
+			b.arg(query.After.CreatedAt) + ", " + b.arg(query.After.ID) + ")"
+	}
+
+	limit := uint64(ITEMS_PER_PAGE)
+	if query.After == nil && query.Page > 1 {
+		limit *= query.Page
+	}
+
+	return `images.id IN (
+		SELECT recent.id
+		FROM follows
+		CROSS JOIN LATERAL (
+			SELECT followed.id
+			FROM images AS followed
+			WHERE followed.user_id = follows.followee_id
+				AND followed.visibility = 'public'
+				AND followed.hidden_at IS NULL
+				AND followed.deleted_at IS NULL
+				AND followed.status = 'published'` + after + `
+			ORDER BY followed.created_at DESC, followed.id DESC
+			LIMIT ` + b.arg(limit) + `
+		) AS recent
+		WHERE follows.follower_id = ` + follower + `
+	)`
+}
+
+const ITEMS_PER_PAGE = 20
+
+func (r *PGXImagesRepository) FindMany(
+	ctx context.Context,
+	page uint64,
+) ([]models.Post, error) {
+	posts, _, err := r.Feed(ctx, FeedQuery{Sort: SortNewest, Page: page})
+	return posts, err
+}
+
+// Feed returns a page of posts and the cursor to the next one, which is nil
+// once the feed is exhausted.
+func (r *PGXImagesRepository) Feed(
+	ctx context.Context,
+	query FeedQuery,
+) ([]models.Post, *Cursor, error) {
+	if !query.Sort.Valid() {
+		query.Sort = SortNewest
+	}
+
+	sql, args := buildFeedQuery(query)
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	return scanPosts(rows, query.Sort)
+}
+
+func scanPosts(rows pgx.Rows, sort FeedSort) ([]models.Post, *Cursor, error) {
+	var (
+		posts []models.Post
+		score float64
+	)
+
+	for rows.Next() {
+		var post models.Post
+
+		err := rows.Scan(append(postFields(&post), &score)...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(posts) < ITEMS_PER_PAGE {
+		return posts, nil, nil
+	}
+
+	last := posts[len(posts)-1].Image
+	return posts, &Cursor{
+		Kind:      sort.CursorKind(),
+		Score:     score,
+		CreatedAt: last.CreatedAt.Time,
+		ID:        last.ID,
+	}, nil
+}
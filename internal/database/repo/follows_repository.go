@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FollowsRepository interface {
+	Follow(ctx context.Context, followerID, followeeID uuid.UUID) error
+	Unfollow(ctx context.Context, followerID, followeeID uuid.UUID) error
+	// Followers and Following return a page of accounts, most recently
+	// followed first, and the cursor to the next one.
+	Followers(ctx context.Context, userID uuid.UUID, after *Cursor) ([]models.FollowUser, *Cursor, error)
+	Following(ctx context.Context, userID uuid.UUID, after *Cursor) ([]models.FollowUser, *Cursor, error)
+	Counts(ctx context.Context, userID uuid.UUID) (*models.FollowCounts, error)
+}
+
+type PGXFollowsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXFollowsRepository(db *pgxpool.Pool) FollowsRepository {
+	return &PGXFollowsRepository{db}
+}
+
+const followQuery = `
+	INSERT INTO follows (follower_id, followee_id)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING;
+`
+
+func (r *PGXFollowsRepository) Follow(ctx context.Context, followerID, followeeID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, followQuery, followerID, followeeID)
+	return err
+}
+
+const unfollowQuery = `
+	DELETE FROM follows
+	WHERE follower_id = $1 AND followee_id = $2;
+`
+
+func (r *PGXFollowsRepository) Unfollow(ctx context.Context, followerID, followeeID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, unfollowQuery, followerID, followeeID)
+	return err
+}
+
+func (r *PGXFollowsRepository) Followers(
+	ctx context.Context,
+	userID uuid.UUID,
+	after *Cursor,
+) ([]models.FollowUser, *Cursor, error) {
+	return r.list(ctx, CursorFollowers, "follower_id", "followee_id", userID, after)
+}
+
+func (r *PGXFollowsRepository) Following(
+	ctx context.Context,
+	userID uuid.UUID,
+	after *Cursor,
+) ([]models.FollowUser, *Cursor, error) {
+	return r.list(ctx, CursorFollowing, "followee_id", "follower_id", userID, after)
+}
+
+// list returns the users in the listed column of the follows where the other
+// column is userID, the next cursor is tagged with kind.
+func (r *PGXFollowsRepository) list(
+	ctx context.Context,
+	kind CursorKind,
+	listed, by string,
+	userID uuid.UUID,
+	after *Cursor,
+) ([]models.FollowUser, *Cursor, error) {
+	var b queryBuilder
+
+	b.where("follows." + by + " = " + b.arg(userID))
+	if after != nil {
+		b.where(fmt.Sprintf(
+			"(follows.created_at, follows.%s) < (%s, %s)",
+			listed,
+			b.arg(after.CreatedAt),
+			b.arg(after.ID),
+		))
+	}
+
+	sql := `
+	SELECT users.id, users.username, users.profile_picture_url, follows.created_at
+	FROM follows
+	JOIN users ON users.id = follows.` + listed +
+		b.whereClause() + `
+	ORDER BY follows.created_at DESC, follows.` + listed + ` DESC
+	LIMIT ` + b.arg(ITEMS_PER_PAGE)
+
+	rows, err := r.db.Query(ctx, sql, b.args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var users []models.FollowUser
+	for rows.Next() {
+		var user models.FollowUser
+
+		err := rows.Scan(&user.ID, &user.Username, &user.Avatar, &user.FollowedAt)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(users) < ITEMS_PER_PAGE {
+		return users, nil, nil
+	}
+
+	last := users[len(users)-1]
+	return users, &Cursor{
+		Kind:      kind,
+		CreatedAt: last.FollowedAt.Time,
+		ID:        last.ID,
+	}, nil
+}
+
+const followCountsQuery = `
+	SELECT
+		(SELECT COUNT(*) FROM follows WHERE followee_id = $1),
+		(SELECT COUNT(*) FROM follows WHERE follower_id = $1);
+`
+
+func (r *PGXFollowsRepository) Counts(
+	ctx context.Context,
+	userID uuid.UUID,
+) (*models.FollowCounts, error) {
+	var counts models.FollowCounts
+	err := r.db.QueryRow(ctx, followCountsQuery, userID).Scan(&counts.Followers, &counts.Following)
+	if err != nil {
+		return nil, err
+	}
+
+	return &counts, nil
+}
@@ -2,9 +2,12 @@ package repo
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/licenses"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -20,7 +23,29 @@ type ImagesRepository interface {
 	) ([]models.Image, error)
 
 	FindMany(ctx context.Context, page uint64) ([]models.Post, error)
-	FindByID(ctx context.Context, id uuid.UUID) (*models.Image, error)
+	Feed(ctx context.Context, query FeedQuery) ([]models.Post, *Cursor, error)
+	Search(ctx context.Context, query SearchQuery) ([]models.SearchResult, *Cursor, error)
+	// FindByID returns the image if viewerID can see it, private images are
+	// only found by their owner. viewerID may be uuid.Nil.
+	FindByID(ctx context.Context, id, viewerID uuid.UUID) (*models.Image, error)
+	// FindListed returns the image if it is shown in feeds and search.
+	FindListed(ctx context.Context, id uuid.UUID) (*models.Image, error)
+	// FindShared returns the image if anyone with its link can open it and it
+	// was not hidden by moderators.
+	FindShared(ctx context.Context, id uuid.UUID) (*models.Image, error)
+	// Update records a new revision when the title, author, description or
+	// url change.
+	Update(ctx context.Context, image *models.Image, edit Edit) error
+
+	// Publish publishes the draft or scheduled image right away.
+	Publish(ctx context.Context, imageID uuid.UUID) error
+	// PublishDue publishes the scheduled images that are due and returns
+	// their ids.
+	PublishDue(ctx context.Context) ([]uuid.UUID, error)
+
+	// Revisions returns the revisions of the image, latest first.
+	Revisions(ctx context.Context, imageID uuid.UUID) ([]models.ImageRevision, error)
+	FindRevision(ctx context.Context, imageID uuid.UUID, number int) (*models.ImageRevision, error)
 }
 
 type PGXImagesRepository struct {
@@ -33,25 +58,130 @@ func NewPGXImagesRepository(db *pgxpool.Pool) ImagesRepository {
 	}
 }
 
-const findImageByIDQuery = "SELECT * FROM images WHERE id = $1;"
+// imageColumns lists the columns scanned by imageFields, in order.
+const imageColumns = `
+	images.id,
+	images.user_id,
+	images.title,
+	images.author,
+	images.description,
+	images.url,
+	images.language::text,
+	images.visibility,
+	images.status,
+	images.publish_at,
+	images.license,
+	images.source_url,
+	COALESCE((
+		SELECT array_agg(image_colors.hex ORDER BY image_colors.position)
+		FROM image_colors
+		WHERE image_colors.image_id = images.id
+	), '{}') AS palette,
+	images.width,
+	images.height,
+	COALESCE((
+		SELECT array_agg(tags.name ORDER BY tags.name)
+		FROM image_tags
+		JOIN tags ON tags.id = image_tags.tag_id
+		WHERE image_tags.image_id = images.id
+	), '{}') AS tags,
+	COALESCE((
+		SELECT array_agg(tags.name ORDER BY tags.name)
+		FROM image_tags
+		JOIN tags ON tags.id = image_tags.tag_id
+		WHERE image_tags.image_id = images.id AND image_tags.explicit
+	), '{}') AS explicit_tags,
+	COALESCE((
+		SELECT jsonb_agg(jsonb_build_object(
+			'userId', mentioned.id,
+			'username', mentioned.username,
+			'start', image_mentions.start_offset,
+			'end', image_mentions.end_offset
+		) ORDER BY image_mentions.start_offset)
+		FROM image_mentions
+		JOIN users AS mentioned ON mentioned.id = image_mentions.user_id
+		WHERE image_mentions.image_id = images.id
+	), '[]'::jsonb) AS mentions,
+	images.created_at,
+	images.updated_at`
+
+// licenseField scans a license id into the full license, so responses carry
+// its terms along with the id.
+type licenseField struct {
+	license *models.License
+}
 
-func (r *PGXImagesRepository) FindByID(
-	ctx context.Context,
-	id uuid.UUID,
-) (*models.Image, error) {
-	row := r.db.QueryRow(ctx, findImageByIDQuery, id)
+func (f licenseField) Scan(src any) error {
+	id, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into a license", src)
+	}
 
-	var image models.Image
-	err := row.Scan(
+	license, ok := licenses.Find(id)
+	if !ok {
+		license = models.License{ID: id, Name: id}
+	}
+	*f.license = license
+
+	return nil
+}
+
+func imageFields(image *models.Image) []any {
+	return []any{
 		&image.ID,
 		&image.UserID,
 		&image.Title,
 		&image.Author,
 		&image.Description,
 		&image.URL,
+		&image.Language,
+		&image.Visibility,
+		&image.Status,
+		&image.PublishAt,
+		licenseField{&image.License},
+		&image.SourceURL,
+		&image.Palette,
+		&image.Width,
+		&image.Height,
+		&image.Tags,
+		&image.ExplicitTags,
+		&image.Mentions,
 		&image.CreatedAt,
 		&image.UpdatedAt,
-	)
+	}
+}
+
+// visibleTo is a condition matching the images viewer can open, listed or not.
+// Drafts, scheduled and private images are only opened by their owner and
+// images in the trash by no one.
+func visibleTo(viewer string) string {
+	return "(images.visibility <> 'private' AND images.status = 'published' OR images.user_id = " +
+		viewer + ") AND images.deleted_at IS NULL"
+}
+
+// published is a condition matching the images that went live and were not
+// deleted.
+const published = "images.status = 'published' AND images.deleted_at IS NULL"
+
+// listed is a condition matching the images shown in feeds and search.
+const listed = "images.visibility = 'public' AND images.hidden_at IS NULL AND " + published
+
+// shared is a condition matching the images shown to anyone with their link,
+// listed ones and unlisted ones that were not hidden by moderators.
+const shared = "images.visibility <> 'private' AND images.hidden_at IS NULL AND " + published
+
+var findImageByIDQuery = "SELECT " + imageColumns + `
+	FROM images
+	WHERE images.id = $1 AND ` + visibleTo("$2") + ";"
+
+func (r *PGXImagesRepository) FindByID(
+	ctx context.Context,
+	id, viewerID uuid.UUID,
+) (*models.Image, error) {
+	row := r.db.QueryRow(ctx, findImageByIDQuery, id, viewerID)
+
+	var image models.Image
+	err := row.Scan(imageFields(&image)...)
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +189,35 @@ func (r *PGXImagesRepository) FindByID(
 	return &image, nil
 }
 
-const getImagesByUserIDQuery = "SELECT * FROM images WHERE user_id = $1"
+const findListedImageQuery = "SELECT " + imageColumns + `
+	FROM images
+	WHERE images.id = $1 AND ` + listed + ";"
+
+func (r *PGXImagesRepository) FindListed(ctx context.Context, id uuid.UUID) (*models.Image, error) {
+	var image models.Image
+	err := r.db.QueryRow(ctx, findListedImageQuery, id).Scan(imageFields(&image)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &image, nil
+}
+
+const findSharedImageQuery = "SELECT " + imageColumns + `
+	FROM images
+	WHERE images.id = $1 AND ` + shared + ";"
+
+func (r *PGXImagesRepository) FindShared(ctx context.Context, id uuid.UUID) (*models.Image, error) {
+	var image models.Image
+	err := r.db.QueryRow(ctx, findSharedImageQuery, id).Scan(imageFields(&image)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &image, nil
+}
+
+const getImagesByUserIDQuery = "SELECT " + imageColumns + " FROM images WHERE user_id = $1 AND deleted_at IS NULL"
 
 func (r *PGXImagesRepository) GetImagesByUserID(
 	ctx context.Context,
@@ -75,17 +233,7 @@ func (r *PGXImagesRepository) GetImagesByUserID(
 	for rows.Next() {
 		var image models.Image
 
-		err := rows.Scan(
-			&image.ID,
-			&image.UserID,
-			&image.Title,
-			&image.Author,
-			&image.Description,
-			&image.URL,
-
-			&image.CreatedAt,
-			&image.UpdatedAt,
-		)
+		err := rows.Scan(imageFields(&image)...)
 		if err != nil {
 			return nil, err
 		}
@@ -102,8 +250,22 @@ const createImageQuery = `
 		"title",
 		"author",
 		"description",
-		"url"
-	) VALUES ($1, $2, $3, $4, $5)
+		"url",
+		"language",
+		"visibility",
+		"license",
+		"source_url",
+		"status",
+		"publish_at"
+	) VALUES (
+		$1, $2, $3, $4, $5,
+		COALESCE(NULLIF($6, ''), 'english')::regconfig,
+		COALESCE(NULLIF($7, ''), 'public'),
+		$8,
+		$9,
+		COALESCE(NULLIF($10, ''), 'published'),
+		$11
+	)
 	RETURNING "id";
 `
 
@@ -111,7 +273,13 @@ func (r *PGXImagesRepository) Create(
 	ctx context.Context,
 	image *models.Image,
 ) (uuid.UUID, error) {
-	row := r.db.QueryRow(
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	row := tx.QueryRow(
 		ctx,
 		createImageQuery,
 		image.UserID,
@@ -119,95 +287,167 @@ func (r *PGXImagesRepository) Create(
 		image.Author,
 		image.Description,
 		image.URL,
+		image.Language,
+		image.Visibility,
+		image.License.ID,
+		image.SourceURL,
+		image.Status,
+		image.PublishAt,
 	)
 
 	var id uuid.UUID
-	err := row.Scan(&id)
-	return id, err
+	if err := row.Scan(&id); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := setImageTags(ctx, tx, id, image.Tags, image.ExplicitTags); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := setMentions(ctx, tx, "image_mentions", "image_id", id, image.Mentions); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := recordRevision(ctx, tx, id, Edit{EditorID: image.UserID}); err != nil {
+		return uuid.Nil, err
+	}
+
+	return id, tx.Commit(ctx)
 }
 
-const getImageByIDQuery = "SELECT * FROM images WHERE id = $1;"
+const updateImageQuery = `
+	UPDATE images SET
+		"title" = $1,
+		"author" = $2,
+		"description" = $3,
+		"url" = $4,
+		"language" = $5::regconfig,
+		"visibility" = $6,
+		"license" = $7,
+		"source_url" = $8,
+		"status" = $10,
+		"publish_at" = $11,
+		"palette_extracted_at" = CASE
+			WHEN "url" = $4 THEN "palette_extracted_at"
+		END,
+		"width" = CASE WHEN "url" = $4 THEN "width" END,
+		"height" = CASE WHEN "url" = $4 THEN "height" END,
+		"updated_at" = NOW()
+	WHERE id = $9;
+`
 
-func (r *PGXImagesRepository) GetImageByID(
-	ctx context.Context,
-	imageID uuid.UUID,
-) (*models.Image, error) {
-	row := r.db.QueryRow(ctx, getImageByIDQuery, imageID)
+// The colors of a replaced image are dropped until the new ones are
+// extracted.
+const clearStalePaletteQuery = `
+	DELETE FROM image_colors
+	WHERE image_id = $1
+		AND (SELECT palette_extracted_at FROM images WHERE id = $1) IS NULL;
+`
 
-	var image models.Image
-	err := row.Scan(
-		&image.ID,
-		&image.UserID,
-		&image.Title,
-		&image.Author,
-		&image.Description,
-		&image.URL,
-		&image.CreatedAt,
-		&image.UpdatedAt,
+func (r *PGXImagesRepository) Update(ctx context.Context, image *models.Image, edit Edit) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(
+		ctx,
+		updateImageQuery,
+		image.Title,
+		image.Author,
+		image.Description,
+		image.URL,
+		image.Language,
+		image.Visibility,
+		image.License.ID,
+		image.SourceURL,
+		image.ID,
+		image.Status,
+		image.PublishAt,
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return &image, nil
-}
+	if _, err := tx.Exec(ctx, clearStalePaletteQuery, image.ID); err != nil {
+		return err
+	}
 
-const findManyQuery = `
-	SELECT
-		images.id,
-		images.user_id,
-		images.title,
-		images.author,
-		images.description,
-		images.url,
-		images.created_at,
-		images.updated_at,
-		users.username,
-		users.profile_picture_url
-	FROM images
-	JOIN users ON images.user_id = users.id
-	LIMIT $1
-	OFFSET $2;
-`
+	if err := setImageTags(ctx, tx, image.ID, image.Tags, image.ExplicitTags); err != nil {
+		return err
+	}
+
+	if err := setMentions(ctx, tx, "image_mentions", "image_id", image.ID, image.Mentions); err != nil {
+		return err
+	}
 
-const ITEMS_PER_PAGE = 20
+	if err := recordRevision(ctx, tx, image.ID, edit); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+const (
+	createTagsQuery = `
+		INSERT INTO tags (name)
+		SELECT unnest($1::text[])
+		ON CONFLICT (name) DO NOTHING;
+	`
+	linkImageTagsQuery = `
+		INSERT INTO image_tags (image_id, tag_id, explicit)
+		SELECT $1, id, name = ANY($3::text[]) FROM tags WHERE name = ANY($2::text[])
+		ON CONFLICT (image_id, tag_id) DO UPDATE SET explicit = EXCLUDED.explicit;
+	`
+	unlinkImageTagsQuery = `
+		DELETE FROM image_tags
+		WHERE image_id = $1
+			AND tag_id NOT IN (SELECT id FROM tags WHERE name = ANY($2::text[]));
+	`
+)
 
-func (r *PGXImagesRepository) FindMany(
+// setImageTags makes tags the exact set of tags of the image, creating the
+// ones that do not exist yet. Those in explicit are marked as set by the owner.
+func setImageTags(
 	ctx context.Context,
-	page uint64,
-) ([]models.Post, error) {
-	skip := (page - 1) * ITEMS_PER_PAGE
+	tx pgx.Tx,
+	imageID uuid.UUID,
+	tags, explicit []string,
+) error {
+	if tags == nil {
+		tags = []string{}
+	}
 
-	rows, err := r.db.Query(ctx, findManyQuery, ITEMS_PER_PAGE, skip)
-	if err != nil {
-		return nil, err
+	if explicit == nil {
+		explicit = []string{}
 	}
-	defer rows.Close()
 
-	var posts []models.Post
-	for rows.Next() {
-		var post models.Post
-		var image models.Image
+	if _, err := tx.Exec(ctx, createTagsQuery, tags); err != nil {
+		return err
+	}
 
-		err := rows.Scan(
-			&image.ID,
-			&image.UserID,
-			&image.Title,
-			&image.Author,
-			&image.Description,
-			&image.URL,
-			&image.CreatedAt,
-			&image.UpdatedAt,
-			&post.Username,
-			&post.Avatar,
-		)
-		if err != nil {
-			return nil, err
-		}
+	if _, err := tx.Exec(ctx, unlinkImageTagsQuery, imageID, tags); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, linkImageTagsQuery, imageID, tags, explicit)
+	return err
+}
 
-		post.Image = image
-		posts = append(posts, post)
+const getImageByIDQuery = "SELECT " + imageColumns + " FROM images WHERE id = $1;"
+
+func (r *PGXImagesRepository) GetImageByID(
+	ctx context.Context,
+	imageID uuid.UUID,
+) (*models.Image, error) {
+	row := r.db.QueryRow(ctx, getImageByIDQuery, imageID)
+
+	var image models.Image
+	err := row.Scan(imageFields(&image)...)
+	if err != nil {
+		return nil, err
 	}
 
-	return posts, nil
+	return &image, nil
 }
@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ImportsRepository interface {
+	Create(ctx context.Context, job *models.ImportJob) error
+	FindByID(ctx context.Context, jobID uuid.UUID) (*models.ImportJob, error)
+	// Claim marks the oldest pending job as running and returns it, along
+	// with running jobs not updated for longer than stale, which were left
+	// behind by a stopped server. It returns pgx.ErrNoRows when there is none.
+	Claim(ctx context.Context, stale time.Duration) (*models.ImportJob, error)
+	// AddResult records the outcome of the next row of the job.
+	AddResult(ctx context.Context, jobID uuid.UUID, result models.ImportResult) error
+	// Finish completes the job, or fails it when failure is set.
+	Finish(ctx context.Context, jobID uuid.UUID, failure *string) error
+}
+
+type PGXImportsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXImportsRepository(db *pgxpool.Pool) ImportsRepository {
+	return &PGXImportsRepository{db}
+}
+
+const importJobColumns = `
+	id,
+	user_id,
+	format,
+	status,
+	total,
+	processed,
+	succeeded,
+	processed - succeeded,
+	report,
+	error,
+	created_at,
+	updated_at,
+	finished_at`
+
+func importJobFields(job *models.ImportJob) []any {
+	return []any{
+		&job.ID,
+		&job.UserID,
+		&job.Format,
+		&job.Status,
+		&job.Total,
+		&job.Processed,
+		&job.Succeeded,
+		&job.Failed,
+		&job.Report,
+		&job.Error,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.FinishedAt,
+	}
+}
+
+const createImportJobQuery = `
+	INSERT INTO import_jobs (id, user_id, format, total)
+	VALUES ($1, $2, $3, $4)
+	RETURNING ` + importJobColumns + `;
+`
+
+// Create inserts the job with the id, user, format and total it was given
+// and fills in the rest.
+func (r *PGXImportsRepository) Create(ctx context.Context, job *models.ImportJob) error {
+	return r.db.QueryRow(
+		ctx,
+		createImportJobQuery,
+		job.ID,
+		job.UserID,
+		job.Format,
+		job.Total,
+	).Scan(importJobFields(job)...)
+}
+
+const findImportJobQuery = "SELECT " + importJobColumns + " FROM import_jobs WHERE id = $1;"
+
+func (r *PGXImportsRepository) FindByID(ctx context.Context, jobID uuid.UUID) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := r.db.QueryRow(ctx, findImportJobQuery, jobID).Scan(importJobFields(&job)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+const claimImportJobQuery = `
+	UPDATE import_jobs SET status = 'running', updated_at = NOW()
+	WHERE id = (
+		SELECT id FROM import_jobs
+		WHERE status = 'pending'
+			OR (status = 'running' AND updated_at < NOW() - make_interval(secs => $1))
+		ORDER BY created_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + importJobColumns + `;
+`
+
+func (r *PGXImportsRepository) Claim(ctx context.Context, stale time.Duration) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := r.db.QueryRow(ctx, claimImportJobQuery, stale.Seconds()).Scan(importJobFields(&job)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+const addImportResultQuery = `
+	UPDATE import_jobs SET
+		processed = processed + 1,
+		succeeded = succeeded + CASE WHEN $3 THEN 1 ELSE 0 END,
+		report = report || jsonb_build_array($2::jsonb),
+		updated_at = NOW()
+	WHERE id = $1;
+`
+
+func (r *PGXImportsRepository) AddResult(
+	ctx context.Context,
+	jobID uuid.UUID,
+	result models.ImportResult,
+) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, addImportResultQuery, jobID, string(data), result.ImageID != nil)
+	return err
+}
+
+const finishImportJobQuery = `
+	UPDATE import_jobs SET
+		status = CASE WHEN $2::text IS NULL THEN 'completed' ELSE 'failed' END,
+		error = $2,
+		updated_at = NOW(),
+		finished_at = NOW()
+	WHERE id = $1;
+`
+
+func (r *PGXImportsRepository) Finish(ctx context.Context, jobID uuid.UUID, failure *string) error {
+	_, err := r.db.Exec(ctx, finishImportJobQuery, jobID, failure)
+	return err
+}
@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// setMentions makes mentions the exact set of mentions stored in table for
+// ownerID.
+func setMentions(
+	ctx context.Context,
+	tx pgx.Tx,
+	table, ownerColumn string,
+	ownerID uuid.UUID,
+	mentions []models.Mention,
+) error {
+	_, err := tx.Exec(ctx, "DELETE FROM "+table+" WHERE "+ownerColumn+" = $1;", ownerID)
+	if err != nil || len(mentions) == 0 {
+		return err
+	}
+
+	users := make([]uuid.UUID, 0, len(mentions))
+	starts := make([]int32, 0, len(mentions))
+	ends := make([]int32, 0, len(mentions))
+	for _, mention := range mentions {
+		users = append(users, mention.UserID)
+		starts = append(starts, int32(mention.Start))
+		ends = append(ends, int32(mention.End))
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		"INSERT INTO "+table+" ("+ownerColumn+", user_id, start_offset, end_offset)\n"+
+			"\tSELECT $1, * FROM unnest($2::uuid[], $3::int[], $4::int[]);",
+		ownerID,
+		users,
+		starts,
+		ends,
+	)
+
+	return err
+}
@@ -0,0 +1,278 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type NotificationType string
+
+const (
+	NotificationComment NotificationType = "comment"
+	NotificationReply   NotificationType = "reply"
+	NotificationLike    NotificationType = "like"
+	NotificationFollow  NotificationType = "follow"
+	NotificationMention NotificationType = "mention"
+)
+
+// NotificationEvent is something ActorID did that UserID should hear about.
+// Events are grouped by type and target, the comment when CommentID is set
+// and the image otherwise.
+type NotificationEvent struct {
+	UserID    uuid.UUID
+	ActorID   uuid.UUID
+	Type      NotificationType
+	ImageID   *uuid.UUID
+	CommentID *uuid.UUID
+}
+
+type NotificationsRepository interface {
+	// Create adds the event to the unread notification of its group, or
+	// starts a new one. Events users cause on their own content and events of
+	// actors the user muted or blocked are dropped.
+	Create(ctx context.Context, event *NotificationEvent) error
+	FindByUserID(ctx context.Context, userID uuid.UUID, after *Cursor) ([]models.Notification, *Cursor, error)
+	FindByID(ctx context.Context, userID, id uuid.UUID) (*models.Notification, error)
+	// FindAfter returns up to limit notifications of the user updated after
+	// the cursor, oldest first.
+	FindAfter(ctx context.Context, userID uuid.UUID, after *Cursor, limit int) ([]models.Notification, error)
+	CountUnread(ctx context.Context, userID uuid.UUID) (int64, error)
+	// MarkRead returns false if the user has no such notification.
+	MarkRead(ctx context.Context, userID, id uuid.UUID) (bool, error)
+	MarkAllRead(ctx context.Context, userID uuid.UUID) error
+}
+
+type PGXNotificationsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXNotificationsRepository(db *pgxpool.Pool) NotificationsRepository {
+	return &PGXNotificationsRepository{db}
+}
+
+// Acting again on a grouped notification moves the actor to the front. Events
+// of actors the user muted or blocked are dropped.
+var createNotificationQuery = `
+	WITH notification AS (
+		INSERT INTO notifications (user_id, type, image_id, comment_id)
+		SELECT $1::uuid, $3::varchar, $4::uuid, $5::uuid
+		WHERE NOT ` + silencedBy("$2::uuid", "$1::uuid") + `
+		ON CONFLICT (user_id, group_key) WHERE read_at IS NULL
+		DO UPDATE SET updated_at = NOW()
+		RETURNING id
+	)
+	INSERT INTO notification_actors (notification_id, actor_id)
+	SELECT id, $2 FROM notification
+	ON CONFLICT (notification_id, actor_id) DO UPDATE SET created_at = NOW();
+`
+
+func (r *PGXNotificationsRepository) Create(ctx context.Context, event *NotificationEvent) error {
+	if event.UserID == event.ActorID {
+		return nil
+	}
+
+	_, err := r.db.Exec(
+		ctx,
+		createNotificationQuery,
+		event.UserID,
+		event.ActorID,
+		event.Type,
+		event.ImageID,
+		event.CommentID,
+	)
+
+	return err
+}
+
+const notificationColumns = `
+	notifications.id,
+	notifications.type,
+	notifications.image_id,
+	notifications.comment_id,
+	notifications.read_at IS NOT NULL,
+	COALESCE((
+		SELECT jsonb_agg(jsonb_build_object(
+			'id', users.id,
+			'username', users.username,
+			'avatar', users.profile_picture_url
+		) ORDER BY latest.created_at DESC)
+		FROM (
+			SELECT actor_id, created_at
+			FROM notification_actors
+			WHERE notification_actors.notification_id = notifications.id
+			ORDER BY created_at DESC
+			LIMIT 3
+		) AS latest
+		JOIN users ON users.id = latest.actor_id
+	), '[]'::jsonb) AS actors,
+	(
+		SELECT COUNT(*) FROM notification_actors
+		WHERE notification_actors.notification_id = notifications.id
+	) AS actors_count,
+	notifications.created_at,
+	notifications.updated_at`
+
+func notificationFields(notification *models.Notification) []any {
+	return []any{
+		&notification.ID,
+		&notification.Type,
+		&notification.ImageID,
+		&notification.CommentID,
+		&notification.Read,
+		&notification.Actors,
+		&notification.ActorsCount,
+		&notification.CreatedAt,
+		&notification.UpdatedAt,
+	}
+}
+
+func (r *PGXNotificationsRepository) FindByUserID(
+	ctx context.Context,
+	userID uuid.UUID,
+	after *Cursor,
+) ([]models.Notification, *Cursor, error) {
+	var b queryBuilder
+
+	b.where("notifications.user_id = " + b.arg(userID))
+	if after != nil {
+		b.where("(notifications.updated_at, notifications.id) < (" +
+			b.arg(after.CreatedAt) + ", " + b.arg(after.ID) + ")")
+	}
+
+	sql := "SELECT " + notificationColumns + `
+	FROM notifications` +
+		b.whereClause() + `
+	ORDER BY notifications.updated_at DESC, notifications.id DESC
+	LIMIT ` + b.arg(ITEMS_PER_PAGE)
+
+	rows, err := r.db.Query(ctx, sql, b.args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var notification models.Notification
+
+		if err := rows.Scan(notificationFields(&notification)...); err != nil {
+			return nil, nil, err
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(notifications) < ITEMS_PER_PAGE {
+		return notifications, nil, nil
+	}
+
+	last := notifications[len(notifications)-1]
+	return notifications, &Cursor{
+		Kind:      CursorNotifications,
+		CreatedAt: last.UpdatedAt.Time,
+		ID:        last.ID,
+	}, nil
+}
+
+const findNotificationByIDQuery = "SELECT " + notificationColumns + `
+	FROM notifications
+	WHERE notifications.user_id = $1 AND notifications.id = $2;
+`
+
+func (r *PGXNotificationsRepository) FindByID(
+	ctx context.Context,
+	userID, id uuid.UUID,
+) (*models.Notification, error) {
+	var notification models.Notification
+	err := r.db.QueryRow(ctx, findNotificationByIDQuery, userID, id).Scan(notificationFields(&notification)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+const findNotificationsAfterQuery = "SELECT " + notificationColumns + `
+	FROM notifications
+	WHERE notifications.user_id = $1
+		AND (notifications.updated_at, notifications.id) > ($2, $3)
+	ORDER BY notifications.updated_at, notifications.id
+	LIMIT $4;
+`
+
+func (r *PGXNotificationsRepository) FindAfter(
+	ctx context.Context,
+	userID uuid.UUID,
+	after *Cursor,
+	limit int,
+) ([]models.Notification, error) {
+	rows, err := r.db.Query(
+		ctx,
+		findNotificationsAfterQuery,
+		userID,
+		after.CreatedAt,
+		after.ID,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var notification models.Notification
+
+		if err := rows.Scan(notificationFields(&notification)...); err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+const countUnreadNotificationsQuery = `
+	SELECT COUNT(*) FROM notifications
+	WHERE user_id = $1 AND read_at IS NULL;
+`
+
+func (r *PGXNotificationsRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.QueryRow(ctx, countUnreadNotificationsQuery, userID).Scan(&count)
+	return count, err
+}
+
+const markNotificationReadQuery = `
+	UPDATE notifications
+	SET read_at = COALESCE(read_at, NOW())
+	WHERE user_id = $1 AND id = $2;
+`
+
+func (r *PGXNotificationsRepository) MarkRead(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	tag, err := r.db.Exec(ctx, markNotificationReadQuery, userID, id)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+const markAllNotificationsReadQuery = `
+	UPDATE notifications
+	SET read_at = NOW()
+	WHERE user_id = $1 AND read_at IS NULL;
+`
+
+func (r *PGXNotificationsRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, markAllNotificationsReadQuery, userID)
+	return err
+}
@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Lab is a color in the CIE Lab space, where the euclidean distance between
+// two colors approximates how different they look.
+type Lab struct {
+	L float64
+	A float64
+	B float64
+}
+
+// PaletteColor is one of the dominant colors of an image, Weight is the share
+// of the image it covers.
+type PaletteColor struct {
+	Hex    string
+	Lab    Lab
+	Weight float64
+}
+
+// PendingImage is an image whose palette is still to be extracted.
+type PendingImage struct {
+	ID  uuid.UUID
+	URL string
+}
+
+type PalettesRepository interface {
+	// FindPending returns up to limit images waiting for their palette,
+	// oldest first.
+	FindPending(ctx context.Context, limit int) ([]PendingImage, error)
+	// SetPalette replaces the colors and dimensions of the image and marks it
+	// as extracted, unless its url changed since it was read. colors is empty
+	// and the dimensions zero when the image could not be processed, so that
+	// it is not retried forever.
+	SetPalette(ctx context.Context, image PendingImage, colors []PaletteColor, width, height int) error
+}
+
+type PGXPalettesRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXPalettesRepository(db *pgxpool.Pool) PalettesRepository {
+	return &PGXPalettesRepository{db}
+}
+
+const findPendingPalettesQuery = `
+	SELECT id, url
+	FROM images
+	WHERE palette_extracted_at IS NULL AND deleted_at IS NULL
+	ORDER BY created_at
+	LIMIT $1;
+`
+
+func (r *PGXPalettesRepository) FindPending(ctx context.Context, limit int) ([]PendingImage, error) {
+	rows, err := r.db.Query(ctx, findPendingPalettesQuery, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []PendingImage
+	for rows.Next() {
+		var image PendingImage
+		if err := rows.Scan(&image.ID, &image.URL); err != nil {
+			return nil, err
+		}
+
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
+
+const (
+	markPaletteExtractedQuery = `
+		UPDATE images SET
+			palette_extracted_at = NOW(),
+			width = NULLIF($3, 0),
+			height = NULLIF($4, 0)
+		WHERE id = $1 AND url = $2 AND palette_extracted_at IS NULL;
+	`
+	deletePaletteQuery = "DELETE FROM image_colors WHERE image_id = $1;"
+	insertPaletteQuery = `
+		INSERT INTO image_colors (image_id, position, hex, l, a, b, weight)
+		SELECT $1, colors.position - 1, colors.hex, colors.l, colors.a, colors.b, colors.weight
+		FROM unnest($2::text[], $3::real[], $4::real[], $5::real[], $6::real[])
+			WITH ORDINALITY AS colors (hex, l, a, b, weight, position);
+	`
+)
+
+func (r *PGXPalettesRepository) SetPalette(
+	ctx context.Context,
+	image PendingImage,
+	colors []PaletteColor,
+	width, height int,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, markPaletteExtractedQuery, image.ID, image.URL, width, height)
+	if err != nil {
+		return err
+	}
+
+	// The image was replaced or deleted meanwhile, the colors are stale.
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, deletePaletteQuery, image.ID); err != nil {
+		return err
+	}
+
+	hexes := make([]string, len(colors))
+	ls := make([]float64, len(colors))
+	as := make([]float64, len(colors))
+	bs := make([]float64, len(colors))
+	weights := make([]float64, len(colors))
+	for i, color := range colors {
+		hexes[i] = color.Hex
+		ls[i] = color.Lab.L
+		as[i] = color.Lab.A
+		bs[i] = color.Lab.B
+		weights[i] = color.Weight
+	}
+
+	_, err = tx.Exec(ctx, insertPaletteQuery, image.ID, hexes, ls, as, bs, weights)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
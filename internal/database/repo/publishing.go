@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// publishQuery publishes the images matching condition. Their creation time,
+// which feeds are sorted by, becomes the time they went live and their
+// trending score is recomputed to match.
+func publishQuery(condition string) string {
+	return `
+	WITH published AS (
+		UPDATE images SET
+			status = 'published',
+			created_at = NOW(),
+			updated_at = NOW()
+		WHERE images.status <> 'published' AND images.deleted_at IS NULL AND ` + condition + `
+		RETURNING id, created_at
+	), stats AS (
+		UPDATE image_stats SET
+			created_at = published.created_at,
+			hot_score = image_hot_score(
+				image_stats.reactions_count + 2 * image_stats.comments_count,
+				published.created_at
+			)
+		FROM published
+		WHERE image_stats.image_id = published.id
+	)
+	SELECT id FROM published;
+	`
+}
+
+// publish_at is stored in UTC, unlike the other timestamps which are in the
+// time zone of the database.
+var (
+	publishImageQuery = publishQuery("images.id = $1")
+	publishDueQuery   = publishQuery(
+		"images.status = 'scheduled' AND images.publish_at <= NOW() AT TIME ZONE 'UTC'",
+	)
+)
+
+func (r *PGXImagesRepository) Publish(ctx context.Context, imageID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, publishImageQuery, imageID)
+	return err
+}
+
+func (r *PGXImagesRepository) PublishDue(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx, publishDueQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var imageIDs []uuid.UUID
+	for rows.Next() {
+		var imageID uuid.UUID
+		if err := rows.Scan(&imageID); err != nil {
+			return nil, err
+		}
+
+		imageIDs = append(imageIDs, imageID)
+	}
+
+	return imageIDs, rows.Err()
+}
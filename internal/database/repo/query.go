@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// queryBuilder accumulates the WHERE conditions and positional arguments of
+// queries whose filters depend on the request.
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// arg registers a positional argument and returns its placeholder.
+func (b *queryBuilder) arg(value any) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+
+	return "\n\tWHERE " + strings.Join(b.conditions, "\n\t\tAND ")
+}
@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReactionsRepository interface {
+	AddToImage(ctx context.Context, userID, imageID uuid.UUID, emoji string) error
+	RemoveFromImage(ctx context.Context, userID, imageID uuid.UUID, emoji string) error
+	AddToComment(ctx context.Context, userID, commentID uuid.UUID, emoji string) error
+	RemoveFromComment(ctx context.Context, userID, commentID uuid.UUID, emoji string) error
+}
+
+type PGXReactionsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXReactionsRepository(db *pgxpool.Pool) ReactionsRepository {
+	return &PGXReactionsRepository{db}
+}
+
+// Reacting twice with the same emoji is a no-op, a user holds at most one
+// reaction of each kind per target.
+const addImageReactionQuery = `
+	INSERT INTO image_reactions (user_id, image_id, emoji)
+	VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING;
+`
+
+func (r *PGXReactionsRepository) AddToImage(
+	ctx context.Context,
+	userID, imageID uuid.UUID,
+	emoji string,
+) error {
+	_, err := r.db.Exec(ctx, addImageReactionQuery, userID, imageID, emoji)
+	return err
+}
+
+const removeImageReactionQuery = `
+	DELETE FROM image_reactions
+	WHERE user_id = $1 AND image_id = $2 AND emoji = $3;
+`
+
+func (r *PGXReactionsRepository) RemoveFromImage(
+	ctx context.Context,
+	userID, imageID uuid.UUID,
+	emoji string,
+) error {
+	_, err := r.db.Exec(ctx, removeImageReactionQuery, userID, imageID, emoji)
+	return err
+}
+
+const addCommentReactionQuery = `
+	INSERT INTO comment_reactions (user_id, comment_id, emoji)
+	VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING;
+`
+
+func (r *PGXReactionsRepository) AddToComment(
+	ctx context.Context,
+	userID, commentID uuid.UUID,
+	emoji string,
+) error {
+	_, err := r.db.Exec(ctx, addCommentReactionQuery, userID, commentID, emoji)
+	return err
+}
+
+const removeCommentReactionQuery = `
+	DELETE FROM comment_reactions
+	WHERE user_id = $1 AND comment_id = $2 AND emoji = $3;
+`
+
+func (r *PGXReactionsRepository) RemoveFromComment(
+	ctx context.Context,
+	userID, commentID uuid.UUID,
+	emoji string,
+) error {
+	_, err := r.db.Exec(ctx, removeCommentReactionQuery, userID, commentID, emoji)
+	return err
+}
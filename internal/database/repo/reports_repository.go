@@ -0,0 +1,238 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReportTarget string
+
+const (
+	ReportPost    ReportTarget = "post"
+	ReportComment ReportTarget = "comment"
+	ReportUser    ReportTarget = "user"
+)
+
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportResolved  ReportStatus = "resolved"
+	ReportDismissed ReportStatus = "dismissed"
+)
+
+type ModerationAction string
+
+const (
+	// ActionDismiss closes the reports without a violation.
+	ActionDismiss ModerationAction = "dismiss"
+	// ActionResolve closes the reports, the violation was dealt with outside
+	// of the content, e.g. by warning the user.
+	ActionResolve ModerationAction = "resolve"
+	ActionHide    ModerationAction = "hide"
+	ActionRemove  ModerationAction = "remove"
+)
+
+// Resolution closes every open report on Target with Action.
+type Resolution struct {
+	ModeratorID uuid.UUID
+	TargetType  ReportTarget
+	TargetID    uuid.UUID
+	Action      ModerationAction
+}
+
+type ReportsRepository interface {
+	// Create returns false if the reporter already has an open report on the
+	// target.
+	Create(ctx context.Context, report *models.Report) (bool, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Report, error)
+	// FindByStatus returns a page of the reports, oldest first, and the cursor
+	// to the next one.
+	FindByStatus(ctx context.Context, status ReportStatus, after *Cursor) ([]models.Report, *Cursor, error)
+	// Resolve applies the action to the target and closes its open reports in
+	// a single transaction.
+	Resolve(ctx context.Context, resolution *Resolution) error
+}
+
+type PGXReportsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXReportsRepository(db *pgxpool.Pool) ReportsRepository {
+	return &PGXReportsRepository{db}
+}
+
+const reportColumns = `
+	id,
+	reporter_id,
+	target_type,
+	target_id,
+	reason,
+	details,
+	status,
+	action,
+	resolved_by,
+	resolved_at,
+	created_at`
+
+func reportFields(report *models.Report) []any {
+	return []any{
+		&report.ID,
+		&report.ReporterID,
+		&report.TargetType,
+		&report.TargetID,
+		&report.Reason,
+		&report.Details,
+		&report.Status,
+		&report.Action,
+		&report.ResolvedBy,
+		&report.ResolvedAt,
+		&report.CreatedAt,
+	}
+}
+
+const createReportQuery = `
+	INSERT INTO reports (reporter_id, target_type, target_id, reason, details)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (reporter_id, target_type, target_id) WHERE status = 'open'
+	DO NOTHING;
+`
+
+func (r *PGXReportsRepository) Create(ctx context.Context, report *models.Report) (bool, error) {
+	tag, err := r.db.Exec(
+		ctx,
+		createReportQuery,
+		report.ReporterID,
+		report.TargetType,
+		report.TargetID,
+		report.Reason,
+		report.Details,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+const findReportByIDQuery = "SELECT " + reportColumns + " FROM reports WHERE id = $1;"
+
+func (r *PGXReportsRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Report, error) {
+	var report models.Report
+	err := r.db.QueryRow(ctx, findReportByIDQuery, id).Scan(reportFields(&report)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (r *PGXReportsRepository) FindByStatus(
+	ctx context.Context,
+	status ReportStatus,
+	after *Cursor,
+) ([]models.Report, *Cursor, error) {
+	var b queryBuilder
+
+	b.where("status = " + b.arg(status))
+	if after != nil {
+		b.where("(created_at, id) > (" + b.arg(after.CreatedAt) + ", " + b.arg(after.ID) + ")")
+	}
+
+	sql := "SELECT " + reportColumns + `
+	FROM reports` +
+		b.whereClause() + `
+	ORDER BY created_at, id
+	LIMIT ` + b.arg(ITEMS_PER_PAGE)
+
+	rows, err := r.db.Query(ctx, sql, b.args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var reports []models.Report
+	for rows.Next() {
+		var report models.Report
+
+		if err := rows.Scan(reportFields(&report)...); err != nil {
+			return nil, nil, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(reports) < ITEMS_PER_PAGE {
+		return reports, nil, nil
+	}
+
+	last := reports[len(reports)-1]
+	return reports, &Cursor{
+		Kind:      CursorReports,
+		CreatedAt: last.CreatedAt.Time,
+		ID:        last.ID,
+	}, nil
+}
+
+var reportTargetTables = map[ReportTarget]string{
+	ReportPost:    "images",
+	ReportComment: "comments",
+}
+
+const closeReportsQuery = `
+	UPDATE reports
+	SET status = $1, action = $2, resolved_by = $3, resolved_at = NOW()
+	WHERE target_type = $4 AND target_id = $5 AND status = 'open';
+`
+
+func (r *PGXReportsRepository) Resolve(ctx context.Context, resolution *Resolution) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if table, ok := reportTargetTables[resolution.TargetType]; ok {
+		var sql string
+		switch resolution.Action {
+		case ActionHide:
+			sql = fmt.Sprintf("UPDATE %s SET hidden_at = NOW() WHERE id = $1 AND hidden_at IS NULL;", table)
+		case ActionRemove:
+			sql = fmt.Sprintf("DELETE FROM %s WHERE id = $1;", table)
+		}
+
+		if sql != "" {
+			if _, err := tx.Exec(ctx, sql, resolution.TargetID); err != nil {
+				return err
+			}
+		}
+	}
+
+	status := ReportResolved
+	if resolution.Action == ActionDismiss {
+		status = ReportDismissed
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		closeReportsQuery,
+		status,
+		resolution.Action,
+		resolution.ModeratorID,
+		resolution.TargetType,
+		resolution.TargetID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
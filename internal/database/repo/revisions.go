@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Edit tells who changed an image, RevertedFrom is set when the change
+// reverts it to a previous revision.
+type Edit struct {
+	EditorID     uuid.UUID
+	RevertedFrom *int
+}
+
+// The image row is locked by the insert or update preceding this query in the
+// same transaction, so revision numbers cannot be taken twice.
+const recordRevisionQuery = `
+	INSERT INTO image_revisions (
+		image_id, revision, editor_id, reverted_from, title, author, description, url
+	)
+	SELECT
+		images.id,
+		COALESCE(latest.revision, 0) + 1,
+		$2,
+		$3,
+		images.title,
+		images.author,
+		images.description,
+		images.url
+	FROM images
+	LEFT JOIN LATERAL (
+		SELECT revision, title, author, description, url
+		FROM image_revisions
+		WHERE image_revisions.image_id = images.id
+		ORDER BY revision DESC
+		LIMIT 1
+	) AS latest ON true
+	WHERE images.id = $1
+		AND (latest.revision IS NULL
+			OR (latest.title, latest.author, latest.description, latest.url)
+				IS DISTINCT FROM (images.title, images.author, images.description, images.url));
+`
+
+// recordRevision stores the current version of the image as a new revision,
+// unless none of the tracked fields changed since the latest one.
+func recordRevision(ctx context.Context, tx pgx.Tx, imageID uuid.UUID, edit Edit) error {
+	_, err := tx.Exec(ctx, recordRevisionQuery, imageID, edit.EditorID, edit.RevertedFrom)
+	return err
+}
+
+const revisionColumns = `
+	image_id,
+	revision,
+	editor_id,
+	reverted_from,
+	title,
+	author,
+	description,
+	url,
+	created_at`
+
+func revisionFields(revision *models.ImageRevision) []any {
+	return []any{
+		&revision.ImageID,
+		&revision.Revision,
+		&revision.EditorID,
+		&revision.RevertedFrom,
+		&revision.Title,
+		&revision.Author,
+		&revision.Description,
+		&revision.URL,
+		&revision.CreatedAt,
+	}
+}
+
+const findRevisionsQuery = "SELECT " + revisionColumns + `
+	FROM image_revisions
+	WHERE image_id = $1
+	ORDER BY revision DESC;
+`
+
+func (r *PGXImagesRepository) Revisions(
+	ctx context.Context,
+	imageID uuid.UUID,
+) ([]models.ImageRevision, error) {
+	rows, err := r.db.Query(ctx, findRevisionsQuery, imageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.ImageRevision{}
+	for rows.Next() {
+		var revision models.ImageRevision
+		if err := rows.Scan(revisionFields(&revision)...); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+const findRevisionQuery = "SELECT " + revisionColumns + `
+	FROM image_revisions
+	WHERE image_id = $1 AND revision = $2;
+`
+
+func (r *PGXImagesRepository) FindRevision(
+	ctx context.Context,
+	imageID uuid.UUID,
+	number int,
+) (*models.ImageRevision, error) {
+	var revision models.ImageRevision
+	err := r.db.QueryRow(ctx, findRevisionQuery, imageID, number).Scan(revisionFields(&revision)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
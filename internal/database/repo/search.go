@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+)
+
+// SearchQuery describes a page of search results. Language is the text search
+// configuration the text is stemmed with, posts in any language are matched.
+type SearchQuery struct {
+	Text     string
+	Language string
+	// Licenses restricts the results to posts under any of them, empty means
+	// no restriction.
+	Licenses []string
+	After    *Cursor
+	Page     uint64
+}
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+// The ranking is done on ids only, highlighting is expensive and runs on the
+// rows of the requested page alone.
+func buildSearchQuery(query SearchQuery) (string, []any) {
+	var b queryBuilder
+
+	language := b.arg(query.Language) + "::regconfig"
+	tsquery := "websearch_to_tsquery(" + language + ", " + b.arg(query.Text) + ")"
+	rank := "ts_rank_cd(images.search_vector, query)::float8"
+
+	b.where("images.search_vector @@ query")
+	b.where(listed)
+
+	if len(query.Licenses) > 0 {
+		b.where("images.license = ANY(" + b.arg(query.Licenses) + ")")
+	}
+
+	if query.After != nil {
+		b.where("(" + rank + ", images.created_at, images.id) < (" +
+			b.arg(query.After.Score) + ", " +
+			b.arg(query.After.CreatedAt) + ", " +
+			b.arg(query.After.ID) + ")")
+	}
+
+	sql := `
+	WITH matches AS (
+		SELECT images.id, ` + rank + ` AS rank
+		FROM images, ` + tsquery + ` AS query` +
+		b.whereClause() + `
+		ORDER BY rank DESC, images.created_at DESC, images.id DESC
+		LIMIT ` + b.arg(ITEMS_PER_PAGE)
+
+	if query.After == nil && query.Page > 1 {
+		sql += " OFFSET " + b.arg((query.Page-1)*ITEMS_PER_PAGE)
+	}
+
+	sql += `
+	)
+	SELECT ` + postColumns + `,
+		matches.rank,
+		ts_headline(` + language + `, images.title, query, '` + headlineOptions + `'),
+		ts_headline(` + language + `, images.description, query, '` + headlineOptions + `')
+	FROM matches
+	JOIN images ON images.id = matches.id` + postJoins + `
+	CROSS JOIN ` + tsquery + ` AS query
+	ORDER BY matches.rank DESC, images.created_at DESC, images.id DESC;`
+
+	return sql, b.args
+}
+
+func (r *PGXImagesRepository) Search(
+	ctx context.Context,
+	query SearchQuery,
+) ([]models.SearchResult, *Cursor, error) {
+	sql, args := buildSearchQuery(query)
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var result models.SearchResult
+
+		err := rows.Scan(append(
+			postFields(&result.Post),
+			&result.Rank,
+			&result.Highlights.Title,
+			&result.Highlights.Description,
+		)...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(results) < ITEMS_PER_PAGE {
+		return results, nil, nil
+	}
+
+	last := results[len(results)-1]
+	return results, &Cursor{
+		Kind:      CursorSearch,
+		Score:     last.Rank,
+		CreatedAt: last.Image.CreatedAt.Time,
+		ID:        last.Image.ID,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TagsRepository interface {
+	// Popular returns the tags used the most in the last given duration, zero
+	// means all time.
+	Popular(ctx context.Context, within time.Duration, limit int) ([]models.TagCount, error)
+}
+
+type PGXTagsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXTagsRepository(db *pgxpool.Pool) TagsRepository {
+	return &PGXTagsRepository{db}
+}
+
+const popularTagsQuery = `
+	SELECT tags.name, COUNT(*) AS posts
+	FROM image_tags
+	JOIN tags ON tags.id = image_tags.tag_id
+	JOIN images ON images.id = image_tags.image_id
+	WHERE ` + listed + `
+		AND ($1::interval = '0' OR image_tags.created_at >= LOCALTIMESTAMP - $1::interval)
+	GROUP BY tags.name
+	ORDER BY posts DESC, tags.name
+	LIMIT $2;
+`
+
+func (r *PGXTagsRepository) Popular(
+	ctx context.Context,
+	within time.Duration,
+	limit int,
+) ([]models.TagCount, error) {
+	rows, err := r.db.Query(ctx, popularTagsQuery, within, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []models.TagCount
+	for rows.Next() {
+		var tag models.TagCount
+
+		if err := rows.Scan(&tag.Name, &tag.Posts); err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
@@ -0,0 +1,172 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TrashRepository interface {
+	DeleteImage(ctx context.Context, imageID uuid.UUID) error
+	DeleteComment(ctx context.Context, commentID uuid.UUID) error
+	// RestoreImage and RestoreComment return false if the user has nothing
+	// with the id in the trash.
+	RestoreImage(ctx context.Context, userID, imageID uuid.UUID) (bool, error)
+	RestoreComment(ctx context.Context, userID, commentID uuid.UUID) (bool, error)
+	// FindByUserID returns what the user has in the trash, most recently
+	// deleted first. PurgeAt is left for the caller to fill.
+	FindByUserID(ctx context.Context, userID uuid.UUID) (*models.Trash, error)
+	// Purge deletes for good what was put in the trash before the given time
+	// and returns how many posts and comments were deleted. Replies to purged
+	// comments are kept as top level comments.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PGXTrashRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPGXTrashRepository(db *pgxpool.Pool) TrashRepository {
+	return &PGXTrashRepository{db}
+}
+
+const (
+	deleteImageQuery = `
+		UPDATE images SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+	deleteCommentQuery = `
+		UPDATE comments SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+)
+
+func (r *PGXTrashRepository) DeleteImage(ctx context.Context, imageID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, deleteImageQuery, imageID)
+	return err
+}
+
+func (r *PGXTrashRepository) DeleteComment(ctx context.Context, commentID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, deleteCommentQuery, commentID)
+	return err
+}
+
+const (
+	restoreImageQuery = `
+		UPDATE images SET deleted_at = NULL
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL;
+	`
+	restoreCommentQuery = `
+		UPDATE comments SET deleted_at = NULL
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL;
+	`
+)
+
+func (r *PGXTrashRepository) RestoreImage(ctx context.Context, userID, imageID uuid.UUID) (bool, error) {
+	tag, err := r.db.Exec(ctx, restoreImageQuery, imageID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *PGXTrashRepository) RestoreComment(ctx context.Context, userID, commentID uuid.UUID) (bool, error) {
+	tag, err := r.db.Exec(ctx, restoreCommentQuery, commentID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+const (
+	findTrashedImagesQuery = "SELECT " + imageColumns + `,
+		images.deleted_at
+	FROM images
+	WHERE images.user_id = $1 AND images.deleted_at IS NOT NULL
+	ORDER BY images.deleted_at DESC, images.id;
+	`
+	findTrashedCommentsQuery = "SELECT " + commentColumns + `,
+		comments.deleted_at
+	FROM comments
+	JOIN users ON comments.user_id = users.id
+	WHERE comments.user_id = $1 AND comments.deleted_at IS NOT NULL
+	ORDER BY comments.deleted_at DESC, comments.id;
+	`
+)
+
+func (r *PGXTrashRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*models.Trash, error) {
+	trash := models.Trash{
+		Posts:    []models.TrashedImage{},
+		Comments: []models.TrashedComment{},
+	}
+
+	rows, err := r.db.Query(ctx, findTrashedImagesQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trashed models.TrashedImage
+
+		fields := append(imageFields(&trashed.Image), &trashed.DeletedAt)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, err
+		}
+
+		trash.Posts = append(trash.Posts, trashed)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.Query(ctx, findTrashedCommentsQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trashed models.TrashedComment
+
+		fields := append(commentFields(&trashed.Comment), &trashed.DeletedAt)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, err
+		}
+
+		trash.Comments = append(trash.Comments, trashed)
+	}
+
+	return &trash, rows.Err()
+}
+
+const (
+	purgeImagesQuery   = "DELETE FROM images WHERE deleted_at < $1;"
+	purgeCommentsQuery = "DELETE FROM comments WHERE deleted_at < $1;"
+)
+
+func (r *PGXTrashRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	images, err := tx.Exec(ctx, purgeImagesQuery, before)
+	if err != nil {
+		return 0, err
+	}
+
+	comments, err := tx.Exec(ctx, purgeCommentsQuery, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return images.RowsAffected() + comments.RowsAffected(), tx.Commit(ctx)
+}
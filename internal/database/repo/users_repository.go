@@ -2,16 +2,30 @@ package repo
 
 import (
 	"context"
+	"errors"
 
 	"github.com/edulustosa/galleria/internal/database/models"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var ErrUsernameTaken = errors.New("username is already taken")
+
+// usernameTaken reports whether err is the violation of the unique index on
+// usernames, hit when two users claim the same one at once.
+func usernameTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" &&
+		pgErr.ConstraintName == "users_username_idx"
+}
+
 type UsersRepository interface {
 	Create(ctx context.Context, user *models.User) (uuid.UUID, error)
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	// FindByUsername ignores case, usernames are unique regardless of it.
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
 }
 
@@ -23,6 +37,34 @@ func NewPGXUsersRepository(db *pgxpool.Pool) UsersRepository {
 	return &PGXUsersRepository{db}
 }
 
+// userColumns lists the columns scanned by userFields, in order.
+const userColumns = `
+	id,
+	username,
+	email,
+	password_hash,
+	bio,
+	profile_picture_url,
+	is_moderator,
+	default_license,
+	created_at,
+	updated_at`
+
+func userFields(user *models.User) []any {
+	return []any{
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Bio,
+		&user.ProfilePictureURL,
+		&user.IsModerator,
+		&user.DefaultLicense,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	}
+}
+
 const createUser = `
 	INSERT INTO users (
 		"username",
@@ -43,51 +85,54 @@ func (r *PGXUsersRepository) Create(ctx context.Context, user *models.User) (uui
 
 	var id uuid.UUID
 	err := row.Scan(&id)
+	if usernameTaken(err) {
+		return uuid.Nil, ErrUsernameTaken
+	}
+
 	return id, err
 }
 
-const findUserByEmail = "SELECT * FROM users WHERE email = $1;"
+const findUserByEmail = "SELECT " + userColumns + " FROM users WHERE email = $1;"
 
 func (r *PGXUsersRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	row := r.db.QueryRow(ctx, findUserByEmail, email)
 
 	var user models.User
-	err := row.Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.PasswordHash,
-		&user.Bio,
-		&user.ProfilePictureURL,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	err := row.Scan(userFields(&user)...)
 
 	return &user, err
 }
 
-const findByIDQuery = "SELECT * FROM users WHERE id = $1;"
+const findByIDQuery = "SELECT " + userColumns + " FROM users WHERE id = $1;"
 
 func (r *PGXUsersRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	var user models.User
-	err := r.db.QueryRow(ctx, findByIDQuery, id).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.PasswordHash,
-		&user.Bio,
-		&user.ProfilePictureURL,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	err := r.db.QueryRow(ctx, findByIDQuery, id).Scan(userFields(&user)...)
+
+	return &user, err
+}
+
+const findByUsernameQuery = "SELECT " + userColumns + " FROM users WHERE LOWER(username) = LOWER($1);"
+
+func (r *PGXUsersRepository) FindByUsername(
+	ctx context.Context,
+	username string,
+) (*models.User, error) {
+	var user models.User
+	err := r.db.QueryRow(ctx, findByUsernameQuery, username).Scan(userFields(&user)...)
 
 	return &user, err
 }
 
 const update = `
 	UPDATE users
-	SET "username" = $1, "bio" = $2, "profile_picture_url" = $3, "updated_at" = NOW()
-	WHERE id = $4;
+	SET
+		"username" = $1,
+		"bio" = $2,
+		"profile_picture_url" = $3,
+		"default_license" = $4,
+		"updated_at" = NOW()
+	WHERE id = $5;
 `
 
 func (r *PGXUsersRepository) Update(ctx context.Context, user *models.User) error {
@@ -95,8 +140,12 @@ func (r *PGXUsersRepository) Update(ctx context.Context, user *models.User) erro
 		user.Username,
 		user.Bio,
 		user.ProfilePictureURL,
+		user.DefaultLicense,
 		user.ID,
 	)
+	if usernameTaken(err) {
+		return ErrUsernameTaken
+	}
 
 	return err
 }
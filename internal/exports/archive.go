@@ -0,0 +1,175 @@
+package exports
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+)
+
+// maxImageBytes is the size limit of each image in an archive, larger ones
+// are left out and noted in the index.
+const maxImageBytes = 50 << 20
+
+// imageExtensions are the extensions kept from image urls, others are
+// derived from the content type.
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".avif", ".svg"}
+
+// IndexEntry describes an image of the archive. File is empty when the image
+// could not be downloaded, Error then tells why.
+type IndexEntry struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	File     string `json:"file,omitempty"`
+	Metadata string `json:"metadata"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Index is the index.json file at the root of an archive.
+type Index struct {
+	ExportedAt time.Time    `json:"exportedAt"`
+	Images     []IndexEntry `json:"images"`
+}
+
+// download opens the image at url, along with the extension of its file.
+func (e *Exports) download(ctx context.Context, url string) (io.ReadCloser, string, error) {
+	if key, ok := e.files.Key(url); ok {
+		f, err := e.files.Open(key)
+		return f, path.Ext(key), err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	ext := strings.ToLower(path.Ext(req.URL.Path))
+	if !slices.Contains(imageExtensions, ext) {
+		ext = ""
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) > 0 {
+			ext = extensions[0]
+		}
+	}
+
+	return resp.Body, ext, nil
+}
+
+var errImageTooLarge = errors.New("image is larger than 50MB")
+
+// writeImage adds the image to the archive and returns the name of its file.
+// Images are read whole before being added, so that a failed download leaves
+// no partial file behind.
+func (e *Exports) writeImage(ctx context.Context, archive *zip.Writer, image *models.Image) (string, error) {
+	body, ext, err := e.download(ctx, image.URL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxImageBytes+1))
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) > maxImageBytes {
+		return "", errImageTooLarge
+	}
+
+	name := "images/" + image.ID.String() + ext
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+func writeJSON(archive *zip.Writer, name string, v any) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// Write writes the archive of the images to w: every image under images/, its
+// metadata under metadata/ and an index.json listing them. Images that cannot
+// be downloaded are noted in the index, progress is called after each image.
+func (e *Exports) Write(
+	ctx context.Context,
+	w io.Writer,
+	images []models.Image,
+	progress func(processed int) error,
+) error {
+	archive := zip.NewWriter(w)
+	index := Index{ExportedAt: time.Now().UTC(), Images: make([]IndexEntry, 0, len(images))}
+
+	for i := range images {
+		image := &images[i]
+		entry := IndexEntry{
+			ID:       image.ID.String(),
+			Title:    image.Title,
+			Metadata: "metadata/" + image.ID.String() + ".json",
+		}
+
+		file, err := e.writeImage(ctx, archive, image)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err != nil {
+			entry.Error = err.Error()
+		}
+
+		entry.File = file
+		if err := writeJSON(archive, entry.Metadata, image); err != nil {
+			return err
+		}
+
+		index.Images = append(index.Images, entry)
+
+		if progress != nil {
+			if err := progress(i + 1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := writeJSON(archive, "index.json", index); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
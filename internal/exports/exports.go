@@ -0,0 +1,104 @@
+package exports
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/edulustosa/galleria/helpers"
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	// StreamLimit is how many images a gallery can have to be exported right
+	// away, larger ones are exported in the background.
+	StreamLimit = 50
+	// Retention is how long a finished export can be downloaded.
+	Retention = 7 * 24 * time.Hour
+	// fetchTimeout bounds the download of each image.
+	fetchTimeout = time.Minute
+)
+
+var (
+	ErrExportNotFound = errors.New("export not found")
+	ErrExportNotReady = errors.New("export is not ready")
+)
+
+// Exports builds ZIP archives of the galleries of users.
+type Exports struct {
+	exportsRepository repo.ExportsRepository
+	imagesRepository  repo.ImagesRepository
+	files             *storage.Disk
+	client            *http.Client
+}
+
+func New(
+	exportsRepository repo.ExportsRepository,
+	imagesRepository repo.ImagesRepository,
+	files *storage.Disk,
+) *Exports {
+	return &Exports{
+		exportsRepository: exportsRepository,
+		imagesRepository:  imagesRepository,
+		files:             files,
+		client:            helpers.PublicClient(fetchTimeout),
+	}
+}
+
+// archiveKey is where the archive of a job is stored.
+func archiveKey(jobID uuid.UUID) string {
+	return "exports/" + jobID.String() + ".zip"
+}
+
+// Images returns every image of the user, drafts and private ones included.
+func (e *Exports) Images(ctx context.Context, userID uuid.UUID) ([]models.Image, error) {
+	return e.imagesRepository.GetImagesByUserID(ctx, userID)
+}
+
+// Start queues the export of the gallery of the user, or returns the one
+// already underway.
+func (e *Exports) Start(ctx context.Context, userID uuid.UUID, total int) (*models.ExportJob, error) {
+	return e.exportsRepository.Create(ctx, userID, total)
+}
+
+// Get returns an export of the user, with where to download it from once it
+// is completed.
+func (e *Exports) Get(ctx context.Context, userID, jobID uuid.UUID) (*models.ExportJob, error) {
+	job, err := e.exportsRepository.FindByID(ctx, jobID)
+	if err != nil || job.UserID != userID {
+		return nil, ErrExportNotFound
+	}
+
+	if job.Status == "completed" {
+		downloadURL := "/profile/exports/" + job.ID.String() + "/download"
+		expiresAt := job.FinishedAt.Time.Add(Retention)
+		job.DownloadURL = &downloadURL
+		job.ExpiresAt = &expiresAt
+	}
+
+	return job, nil
+}
+
+// Open returns the archive of a completed export of the user.
+func (e *Exports) Open(ctx context.Context, userID, jobID uuid.UUID) (*os.File, error) {
+	job, err := e.Get(ctx, userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status != "completed" {
+		return nil, ErrExportNotReady
+	}
+
+	f, err := e.files.Open(archiveKey(job.ID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrExportNotFound
+	}
+
+	return f, err
+}
@@ -0,0 +1,121 @@
+package exports
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// pollInterval is how often the worker looks for new jobs and expired
+	// archives.
+	pollInterval = 30 * time.Second
+	// staleAfter is how long a running job can go without progress before
+	// another worker starts it over.
+	staleAfter = 10 * time.Minute
+)
+
+// Worker builds the archives of queued exports in the background and deletes
+// them once expired.
+type Worker struct {
+	exports *Exports
+}
+
+func NewWorker(exports *Exports) *Worker {
+	return &Worker{exports}
+}
+
+func (w *Worker) build(ctx context.Context, job *models.ExportJob) error {
+	e := w.exports
+
+	images, err := e.Images(ctx, job.UserID)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(e.Write(ctx, pw, images, func(processed int) error {
+			return e.exportsRepository.SetProgress(ctx, job.ID, processed)
+		}))
+	}()
+
+	err = e.files.Put(archiveKey(job.ID), pr)
+	pr.CloseWithError(err)
+	return err
+}
+
+// ProcessNext builds the archive of the next job and returns false when there
+// was none.
+func (w *Worker) ProcessNext(ctx context.Context) (bool, error) {
+	repository := w.exports.exportsRepository
+
+	job, err := repository.Claim(ctx, staleAfter)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	var failure *string
+	if err := w.build(ctx, job); err != nil {
+		if ctx.Err() != nil {
+			return true, ctx.Err()
+		}
+
+		log.Printf("failed to export gallery %s: %v", job.ID, err)
+		message := "failed to build the archive"
+		failure = &message
+	}
+
+	return true, repository.Finish(ctx, job.ID, failure)
+}
+
+// Expire deletes the exports finished longer than Retention ago, along with
+// their archives.
+func (w *Worker) Expire(ctx context.Context) error {
+	jobIDs, err := w.exports.exportsRepository.DeleteFinishedBefore(ctx, time.Now().Add(-Retention))
+	if err != nil {
+		return err
+	}
+
+	for _, jobID := range jobIDs {
+		if err := w.exports.files.Remove(archiveKey(jobID)); err != nil {
+			log.Printf("failed to remove export %s: %v", jobID, err)
+		}
+	}
+
+	return nil
+}
+
+// Run processes jobs until ctx is done, checking for new ones and expired
+// archives every 30 seconds once it caught up.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		processed, err := w.ProcessNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("failed to process exports: %v", err)
+		}
+
+		if processed && err == nil {
+			continue
+		}
+
+		if err := w.Expire(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("failed to expire exports: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
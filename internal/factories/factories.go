@@ -1,21 +1,135 @@
 package factories
 
 import (
+	"github.com/edulustosa/galleria/internal/albums"
+	"github.com/edulustosa/galleria/internal/analytics"
+	"github.com/edulustosa/galleria/internal/blocks"
+	"github.com/edulustosa/galleria/internal/bookmarks"
 	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/exports"
+	"github.com/edulustosa/galleria/internal/follows"
 	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/imports"
+	"github.com/edulustosa/galleria/internal/moderation"
+	"github.com/edulustosa/galleria/internal/notifications"
+	"github.com/edulustosa/galleria/internal/oembed"
 	"github.com/edulustosa/galleria/internal/profile"
+	"github.com/edulustosa/galleria/internal/reactions"
+	"github.com/edulustosa/galleria/internal/storage"
+	"github.com/edulustosa/galleria/internal/tags"
+	"github.com/edulustosa/galleria/internal/trash"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func MakeProfileService(pool *pgxpool.Pool) *profile.Profile {
 	usersRepository := repo.NewPGXUsersRepository(pool)
 	imagesRepository := repo.NewPGXImagesRepository(pool)
-	return profile.New(usersRepository, imagesRepository)
+	followsRepository := repo.NewPGXFollowsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	return profile.New(usersRepository, imagesRepository, followsRepository, blocksRepository)
 }
 
 func MakeGalleriaService(pool *pgxpool.Pool) *galleria.Galleria {
 	usersRepository := repo.NewPGXUsersRepository(pool)
 	imagesRepository := repo.NewPGXImagesRepository(pool)
 	commentsRepository := repo.NewPGXCommentsRepository(pool)
-	return galleria.New(usersRepository, imagesRepository, commentsRepository)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	return galleria.New(
+		usersRepository,
+		imagesRepository,
+		commentsRepository,
+		notificationsRepository,
+		blocksRepository,
+	)
+}
+
+func MakeReactionsService(pool *pgxpool.Pool) *reactions.Reactions {
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	reactionsRepository := repo.NewPGXReactionsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	return reactions.New(imagesRepository, commentsRepository, reactionsRepository, notificationsRepository)
+}
+
+func MakeTagsService(pool *pgxpool.Pool) *tags.Tags {
+	tagsRepository := repo.NewPGXTagsRepository(pool)
+	return tags.New(tagsRepository)
+}
+
+func MakeAlbumsService(pool *pgxpool.Pool) *albums.Albums {
+	albumsRepository := repo.NewPGXAlbumsRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	return albums.New(albumsRepository, imagesRepository, usersRepository, blocksRepository)
+}
+
+func MakeFollowsService(pool *pgxpool.Pool) *follows.Follows {
+	followsRepository := repo.NewPGXFollowsRepository(pool)
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	return follows.New(
+		followsRepository,
+		usersRepository,
+		imagesRepository,
+		notificationsRepository,
+		blocksRepository,
+	)
+}
+
+func MakeBookmarksService(pool *pgxpool.Pool) *bookmarks.Bookmarks {
+	bookmarksRepository := repo.NewPGXBookmarksRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	return bookmarks.New(bookmarksRepository, imagesRepository)
+}
+
+func MakeNotificationsService(pool *pgxpool.Pool) *notifications.Notifications {
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	return notifications.New(notificationsRepository)
+}
+
+func MakeModerationService(pool *pgxpool.Pool) *moderation.Moderation {
+	reportsRepository := repo.NewPGXReportsRepository(pool)
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	return moderation.New(reportsRepository, usersRepository, imagesRepository, commentsRepository)
+}
+
+func MakeBlocksService(pool *pgxpool.Pool) *blocks.Blocks {
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	return blocks.New(blocksRepository, usersRepository)
+}
+
+func MakeAnalyticsService(pool *pgxpool.Pool) *analytics.Analytics {
+	analyticsRepository := repo.NewPGXAnalyticsRepository(pool)
+	return analytics.New(analyticsRepository)
+}
+
+func MakeTrashService(pool *pgxpool.Pool) *trash.Trash {
+	trashRepository := repo.NewPGXTrashRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	return trash.New(trashRepository, imagesRepository, commentsRepository)
+}
+
+func MakeImportsService(pool *pgxpool.Pool, files *storage.Disk) *imports.Imports {
+	importsRepository := repo.NewPGXImportsRepository(pool)
+	return imports.New(importsRepository, MakeGalleriaService(pool), files)
+}
+
+func MakeExportsService(pool *pgxpool.Pool, files *storage.Disk) *exports.Exports {
+	exportsRepository := repo.NewPGXExportsRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	return exports.New(exportsRepository, imagesRepository, files)
+}
+
+func MakeOEmbedService(pool *pgxpool.Pool, publicURL string) *oembed.OEmbed {
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	return oembed.New(imagesRepository, usersRepository, publicURL)
 }
@@ -0,0 +1,92 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Media   string      `xml:"xmlns:media,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Author    atomAuthor `xml:"author"`
+	Links     []atomLink `xml:"link"`
+	Summary   *atomText  `xml:"summary,omitempty"`
+	Media     mediaContent
+}
+
+// Atom renders the posts as an Atom feed, images are linked as enclosures.
+// Entries are identified by the ids of the posts so that they stay the same
+// if the address of the server changes.
+func Atom(channel Channel, posts []models.Post) ([]byte, error) {
+	updated := Updated(posts)
+	if updated.IsZero() {
+		// Atom requires a date, an empty feed has never changed.
+		updated = time.Unix(0, 0).UTC()
+	}
+
+	feed := atomFeed{
+		Media:   mediaNamespace,
+		ID:      channel.Self,
+		Title:   channel.Title,
+		Updated: updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: channel.Self, Rel: "self", Type: "application/atom+xml"},
+			{Href: channel.Link, Rel: "alternate"},
+		},
+		Entries: make([]atomEntry, len(posts)),
+	}
+
+	for i := range posts {
+		post := &posts[i]
+
+		entry := atomEntry{
+			ID:        "urn:uuid:" + post.Image.ID.String(),
+			Title:     post.Image.Title,
+			Published: post.Image.CreatedAt.Time.UTC().Format(time.RFC3339),
+			Updated:   post.Image.UpdatedAt.Time.UTC().Format(time.RFC3339),
+			Author:    atomAuthor{Name: post.Username},
+			Links: []atomLink{
+				{Href: channel.PostURL(post), Rel: "alternate"},
+				{Href: post.Image.URL, Rel: "enclosure", Type: imageType(post.Image.URL)},
+			},
+			Media: media(&post.Image),
+		}
+
+		if summary := description(&post.Image); summary != "" {
+			entry.Summary = &atomText{Type: "text", Value: summary}
+		}
+
+		feed.Entries[i] = entry
+	}
+
+	return marshal(feed)
+}
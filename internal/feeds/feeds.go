@@ -0,0 +1,91 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"mime"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+)
+
+const mediaNamespace = "http://search.yahoo.com/mrss/"
+
+// Channel describes a feed. Link is the page the feed is about and Self the
+// address of the feed itself.
+type Channel struct {
+	Title       string
+	Description string
+	Link        string
+	Self        string
+	// PostURL returns the address of a post.
+	PostURL func(post *models.Post) string
+}
+
+// Updated is when the posts last changed, the zero time when there are none.
+func Updated(posts []models.Post) time.Time {
+	var updated time.Time
+	for i := range posts {
+		if t := posts[i].Image.UpdatedAt.Time; t.After(updated) {
+			updated = t
+		}
+	}
+
+	return updated.UTC()
+}
+
+// imageType guesses the media type of an image from its url, which is all
+// there is to go on.
+func imageType(imageURL string) string {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return ""
+	}
+
+	return mime.TypeByExtension(path.Ext(u.Path))
+}
+
+// mediaContent is the Media RSS description of an image, understood by
+// readers in both RSS and Atom feeds.
+type mediaContent struct {
+	XMLName xml.Name `xml:"media:content"`
+	URL     string   `xml:"url,attr"`
+	Type    string   `xml:"type,attr,omitempty"`
+	Medium  string   `xml:"medium,attr"`
+	Width   int      `xml:"width,attr,omitempty"`
+	Height  int      `xml:"height,attr,omitempty"`
+	Title   string   `xml:"media:title,omitempty"`
+}
+
+func media(image *models.Image) mediaContent {
+	content := mediaContent{
+		URL:    image.URL,
+		Type:   imageType(image.URL),
+		Medium: "image",
+		Title:  image.Title,
+	}
+
+	if image.Width != nil && image.Height != nil {
+		content.Width, content.Height = *image.Width, *image.Height
+	}
+
+	return content
+}
+
+func description(image *models.Image) string {
+	if image.Description == nil {
+		return ""
+	}
+
+	return *image.Description
+}
+
+func marshal(feed any) ([]byte, error) {
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,101 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+)
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Media   string     `xml:"xmlns:media,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          rssSelf   `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+// rssSelf is the atom:link RSS feeds use to point at themselves.
+type rssSelf struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type rssEnclosure struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+	// Length is required but unknown, 0 is what readers expect then.
+	Length int `xml:"length,attr"`
+}
+
+type rssItem struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	GUID        rssGUID      `xml:"guid"`
+	PubDate     string       `xml:"pubDate"`
+	Description string       `xml:"description,omitempty"`
+	Author      string       `xml:"dc:creator,omitempty"`
+	Enclosure   rssEnclosure `xml:"enclosure"`
+	Media       mediaContent
+}
+
+// RSS renders the posts as an RSS 2.0 feed, images are attached as
+// enclosures.
+func RSS(channel Channel, posts []models.Post) ([]byte, error) {
+	feed := rss{
+		Version: "2.0",
+		Media:   mediaNamespace,
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:       channel.Title,
+			Link:        channel.Link,
+			Description: channel.Description,
+			Self:        rssSelf{Href: channel.Self, Rel: "self", Type: "application/rss+xml"},
+			Items:       make([]rssItem, len(posts)),
+		},
+	}
+
+	if updated := Updated(posts); !updated.IsZero() {
+		feed.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+
+	for i := range posts {
+		post := &posts[i]
+		link := channel.PostURL(post)
+
+		enclosureType := imageType(post.Image.URL)
+		if enclosureType == "" {
+			enclosureType = "application/octet-stream"
+		}
+
+		feed.Channel.Items[i] = rssItem{
+			Title:       post.Image.Title,
+			Link:        link,
+			GUID:        rssGUID{Value: link, IsPermaLink: true},
+			PubDate:     post.Image.CreatedAt.Time.UTC().Format(time.RFC1123Z),
+			Description: description(&post.Image),
+			Author:      post.Username,
+			Enclosure:   rssEnclosure{URL: post.Image.URL, Type: enclosureType},
+			Media:       media(&post.Image),
+		}
+	}
+
+	return marshal(feed)
+}
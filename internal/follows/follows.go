@@ -0,0 +1,166 @@
+package follows
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/notifications"
+	"github.com/google/uuid"
+)
+
+type Follows struct {
+	followsRepository       repo.FollowsRepository
+	usersRepository         repo.UsersRepository
+	imagesRepository        repo.ImagesRepository
+	notificationsRepository repo.NotificationsRepository
+	blocksRepository        repo.BlocksRepository
+}
+
+func New(
+	followsRepository repo.FollowsRepository,
+	usersRepository repo.UsersRepository,
+	imagesRepository repo.ImagesRepository,
+	notificationsRepository repo.NotificationsRepository,
+	blocksRepository repo.BlocksRepository,
+) *Follows {
+	return &Follows{
+		followsRepository:       followsRepository,
+		usersRepository:         usersRepository,
+		imagesRepository:        imagesRepository,
+		notificationsRepository: notificationsRepository,
+		blocksRepository:        blocksRepository,
+	}
+}
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrCannotFollowSelf = errors.New("users cannot follow themselves")
+	ErrBlocked          = errors.New("this user blocked you")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+)
+
+// Follow is idempotent, following an account twice is not an error.
+func (f *Follows) Follow(ctx context.Context, followerID, followeeID uuid.UUID) error {
+	if followerID == followeeID {
+		return ErrCannotFollowSelf
+	}
+
+	if _, err := f.usersRepository.FindByID(ctx, followeeID); err != nil {
+		return ErrUserNotFound
+	}
+
+	blocked, err := f.blocksRepository.IsBlocked(ctx, followeeID, followerID)
+	if err != nil {
+		return err
+	}
+
+	if blocked {
+		return ErrBlocked
+	}
+
+	if err := f.followsRepository.Follow(ctx, followerID, followeeID); err != nil {
+		return err
+	}
+
+	notifications.Send(ctx, f.notificationsRepository, &repo.NotificationEvent{
+		UserID:  followeeID,
+		ActorID: followerID,
+		Type:    repo.NotificationFollow,
+	})
+
+	return nil
+}
+
+func (f *Follows) Unfollow(ctx context.Context, followerID, followeeID uuid.UUID) error {
+	return f.followsRepository.Unfollow(ctx, followerID, followeeID)
+}
+
+// FollowList is a page of a followers or following list.
+type FollowList struct {
+	Users      []models.FollowUser
+	Counts     *models.FollowCounts
+	NextCursor string
+}
+
+func (f *Follows) Followers(ctx context.Context, userID uuid.UUID, cursor string) (*FollowList, error) {
+	return f.list(ctx, userID, cursor, repo.CursorFollowers, f.followsRepository.Followers)
+}
+
+func (f *Follows) Following(ctx context.Context, userID uuid.UUID, cursor string) (*FollowList, error) {
+	return f.list(ctx, userID, cursor, repo.CursorFollowing, f.followsRepository.Following)
+}
+
+type listFunc func(
+	ctx context.Context,
+	userID uuid.UUID,
+	after *repo.Cursor,
+) ([]models.FollowUser, *repo.Cursor, error)
+
+func (f *Follows) list(
+	ctx context.Context,
+	userID uuid.UUID,
+	cursor string,
+	kind repo.CursorKind,
+	list listFunc,
+) (*FollowList, error) {
+	if _, err := f.usersRepository.FindByID(ctx, userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	var after *repo.Cursor
+	if cursor != "" {
+		parsed, err := repo.ParseCursor(cursor)
+		if err != nil || parsed.Kind != kind {
+			return nil, ErrInvalidCursor
+		}
+		after = parsed
+	}
+
+	users, next, err := list(ctx, userID, after)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := f.followsRepository.Counts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FollowList{Users: users, Counts: counts}
+	if next != nil {
+		result.NextCursor = next.String()
+	}
+
+	return result, nil
+}
+
+// Feed returns the newest posts of the accounts the user follows and the
+// cursor to the next page, which is empty once the feed is exhausted.
+func (f *Follows) Feed(
+	ctx context.Context,
+	userID uuid.UUID,
+	cursor string,
+) (posts []models.Post, nextCursor string, err error) {
+	query := repo.FeedQuery{
+		Sort:       repo.SortNewest,
+		FollowedBy: userID,
+		ViewerID:   userID,
+	}
+
+	if cursor != "" {
+		parsed, err := repo.ParseCursor(cursor)
+		if err != nil || parsed.Kind != repo.SortNewest.CursorKind() {
+			return nil, "", ErrInvalidCursor
+		}
+		query.After = parsed
+	}
+
+	posts, next, err := f.imagesRepository.Feed(ctx, query)
+	if err != nil || next == nil {
+		return posts, "", err
+	}
+
+	return posts, next.String(), nil
+}
@@ -3,39 +3,124 @@ package galleria
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/edulustosa/galleria/helpers"
 	"github.com/edulustosa/galleria/internal/database/models"
 	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/licenses"
+	"github.com/edulustosa/galleria/internal/mentions"
+	"github.com/edulustosa/galleria/internal/notifications"
+	"github.com/edulustosa/galleria/internal/palette"
+	"github.com/edulustosa/galleria/internal/tags"
 	"github.com/google/uuid"
 )
 
 type Galleria struct {
-	usersRepository    repo.UsersRepository
-	imagesRepository   repo.ImagesRepository
-	commentsRepository repo.CommentsRepository
+	usersRepository         repo.UsersRepository
+	imagesRepository        repo.ImagesRepository
+	commentsRepository      repo.CommentsRepository
+	notificationsRepository repo.NotificationsRepository
+	blocksRepository        repo.BlocksRepository
 }
 
 func New(
 	usersRepository repo.UsersRepository,
 	imagesRepository repo.ImagesRepository,
 	commentsRepository repo.CommentsRepository,
+	notificationsRepository repo.NotificationsRepository,
+	blocksRepository repo.BlocksRepository,
 ) *Galleria {
 	return &Galleria{
-		usersRepository:    usersRepository,
-		imagesRepository:   imagesRepository,
-		commentsRepository: commentsRepository,
+		usersRepository:         usersRepository,
+		imagesRepository:        imagesRepository,
+		commentsRepository:      commentsRepository,
+		notificationsRepository: notificationsRepository,
+		blocksRepository:        blocksRepository,
 	}
 }
 
 var ErrUserNotFound = errors.New("user not found")
-var ErrImageNotFound = errors.New("user not found")
+var ErrImageNotFound = errors.New("post not found")
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrNotImageOwner = errors.New("image belongs to another user")
+var ErrCommentNotFound = errors.New("comment not found")
+var ErrBlocked = errors.New("the owner of this post blocked you")
+var ErrRevisionNotFound = errors.New("revision not found")
+var ErrAlreadyPublished = errors.New("post is already published")
+var ErrNotScheduled = errors.New("post is not scheduled")
+var ErrTooManyTags = fmt.Errorf("images can have up to %d tags", tags.MaxPerImage)
+
+// Languages are the text search configurations posts can be written in,
+// they drive stemming when indexing and searching.
+var Languages = []string{
+	"simple",
+	"english",
+	"portuguese",
+	"spanish",
+	"french",
+	"german",
+	"italian",
+}
+
+func validLanguage(language string) bool {
+	return slices.Contains(Languages, language)
+}
+
+// Visibilities are who can see a post. Public posts are listed in feeds and
+// search, unlisted ones are only reachable by their link and private ones
+// only by their owner.
+var Visibilities = []string{"public", "unlisted", "private"}
+
+const visibilityProblem = "visibility must be one of public, unlisted or private"
+
+// Statuses are the stages of a post. Drafts and scheduled posts are only seen
+// by their owner until they are published, scheduled ones are published by
+// the Scheduler once their publish time comes.
+var Statuses = []string{"draft", "scheduled", "published"}
+
+const statusProblem = "status must be one of draft, scheduled or published"
+
+// validateSchedule returns the problem with the publish time of a post in the
+// given status, if any.
+func validateSchedule(status string, publishAt *time.Time) string {
+	if status != "scheduled" {
+		if publishAt != nil {
+			return "publish time can only be set on scheduled posts"
+		}
+
+		return ""
+	}
+
+	if publishAt == nil || !publishAt.After(time.Now()) {
+		return "scheduled posts need a publish time in the future"
+	}
+
+	return ""
+}
 
 type SendImageRequest struct {
 	Title       string  `json:"title"`
 	Author      *string `json:"author"`
 	Description *string `json:"description"`
 	URL         string  `json:"url"`
+	// Language defaults to english.
+	Language string `json:"language"`
+	// Tags are merged with the #hashtags found in the description.
+	Tags []string `json:"tags"`
+	// Visibility defaults to public.
+	Visibility string `json:"visibility"`
+	// License defaults to the default license of the user.
+	License string `json:"license"`
+	// SourceURL is where the work was originally published, for attribution.
+	SourceURL *string `json:"sourceUrl"`
+	// Status defaults to published.
+	Status string `json:"status"`
+	// PublishAt is required when the status is scheduled.
+	PublishAt *time.Time `json:"publishAt"`
 }
 
 func (r SendImageRequest) Valid() (problems map[string]string) {
@@ -57,6 +142,136 @@ func (r SendImageRequest) Valid() (problems map[string]string) {
 		problems["url"] = "invalid url scheme"
 	}
 
+	if r.Language != "" && !validLanguage(r.Language) {
+		problems["language"] = "unsupported language"
+	}
+
+	if r.Visibility != "" && !slices.Contains(Visibilities, r.Visibility) {
+		problems["visibility"] = visibilityProblem
+	}
+
+	if r.License != "" && !licenses.Valid(r.License) {
+		problems["license"] = "unknown license"
+	}
+
+	if r.SourceURL != nil && (len(*r.SourceURL) > 512 || helpers.ValidateURL(*r.SourceURL) != nil) {
+		problems["sourceUrl"] = "source url must be an http or https url up to 512 characters"
+	}
+
+	if r.Status != "" && !slices.Contains(Statuses, r.Status) {
+		problems["status"] = statusProblem
+	} else if problem := validateSchedule(r.Status, r.PublishAt); problem != "" {
+		problems["publishAt"] = problem
+	}
+
+	if problem := validateTags(r.Tags, r.Description); problem != "" {
+		problems["tags"] = problem
+	}
+
+	return problems
+}
+
+func validateTags(list []string, description *string) string {
+	for _, tag := range list {
+		if _, ok := tags.Normalize(tag); !ok {
+			return fmt.Sprintf(
+				"tags must have up to %d letters, digits or underscores",
+				tags.MaxLength,
+			)
+		}
+	}
+
+	if len(imageTags(list, description)) > tags.MaxPerImage {
+		return ErrTooManyTags.Error()
+	}
+
+	return ""
+}
+
+func imageTags(list []string, description *string) []string {
+	if description == nil {
+		return tags.Merge(list)
+	}
+
+	return tags.Merge(list, tags.Hashtags(*description))
+}
+
+type UpdateImageRequest struct {
+	Title       *string   `json:"title"`
+	Author      *string   `json:"author"`
+	Description *string   `json:"description"`
+	URL         *string   `json:"url"`
+	Language    *string   `json:"language"`
+	Tags        *[]string `json:"tags"`
+	Visibility  *string   `json:"visibility"`
+	License     *string   `json:"license"`
+	// SourceURL is cleared when set to an empty string.
+	SourceURL *string `json:"sourceUrl"`
+	// Status can move a draft or a scheduled post forward, published posts
+	// cannot go back.
+	Status *string `json:"status"`
+	// PublishAt is required when the status is set to scheduled, on its own
+	// it reschedules a scheduled post.
+	PublishAt *time.Time `json:"publishAt"`
+}
+
+func (r UpdateImageRequest) Valid() (problems map[string]string) {
+	problems = make(map[string]string)
+
+	if r.Title != nil && (*r.Title == "" || len(*r.Title) > 255) {
+		problems["title"] = "title must be between 1 and 255 characters"
+	}
+
+	if r.Author != nil && len(*r.Author) > 50 {
+		problems["author"] = "author must be less than 50 characters"
+	}
+
+	if r.Description != nil && len(*r.Description) > 500 {
+		problems["description"] = "description must be less than 500 characters"
+	}
+
+	if r.URL != nil {
+		if err := helpers.ValidateURL(*r.URL); err != nil {
+			problems["url"] = "invalid url scheme"
+		}
+	}
+
+	if r.Language != nil && !validLanguage(*r.Language) {
+		problems["language"] = "unsupported language"
+	}
+
+	if r.Visibility != nil && !slices.Contains(Visibilities, *r.Visibility) {
+		problems["visibility"] = visibilityProblem
+	}
+
+	if r.License != nil && !licenses.Valid(*r.License) {
+		problems["license"] = "unknown license"
+	}
+
+	if r.SourceURL != nil && *r.SourceURL != "" &&
+		(len(*r.SourceURL) > 512 || helpers.ValidateURL(*r.SourceURL) != nil) {
+		problems["sourceUrl"] = "source url must be an http or https url up to 512 characters"
+	}
+
+	if r.Status != nil && !slices.Contains(Statuses, *r.Status) {
+		problems["status"] = statusProblem
+	} else if r.Status != nil {
+		if problem := validateSchedule(*r.Status, r.PublishAt); problem != "" {
+			problems["publishAt"] = problem
+		}
+	} else if r.PublishAt != nil {
+		// Reschedules the post, UpdateImage checks that it is scheduled.
+		if problem := validateSchedule("scheduled", r.PublishAt); problem != "" {
+			problems["publishAt"] = problem
+		}
+	}
+
+	if r.Tags != nil {
+		if problem := validateTags(*r.Tags, r.Description); problem != "" {
+			problems["tags"] = problem
+		}
+	}
+
 	return problems
 }
 
@@ -68,25 +283,493 @@ func (g *Galleria) Display(ctx context.Context, page uint64) ([]models.Post, err
 	return g.imagesRepository.FindMany(ctx, page)
 }
 
+type BrowseOptions struct {
+	// Sort is one of newest, top or trending, defaults to newest.
+	Sort string
+	// Period restricts the top sort to day, week, month or all, defaults to all.
+	Period string
+	// Tag restricts the feed to posts with the tag.
+	Tag string
+	// Username restricts the feed to posts of the user.
+	Username string
+	// Licenses restricts the feed to posts under any of them.
+	Licenses []string
+	// Color restricts the feed to posts with a dominant color that looks
+	// close to it, written as rrggbb.
+	Color string
+	// ViewerID is the user browsing, uuid.Nil for anonymous visitors. Posts of
+	// the accounts they muted or blocked are left out.
+	ViewerID uuid.UUID
+	Cursor   string
+	Page     uint64
+}
+
+func (o BrowseOptions) Valid() (problems map[string]string) {
+	problems = make(map[string]string)
+
+	if _, ok := tags.Normalize(o.Tag); o.Tag != "" && !ok {
+		problems["tag"] = "invalid tag"
+	}
+
+	if problem := validateLicenses(o.Licenses); problem != "" {
+		problems["license"] = problem
+	}
+
+	if _, err := palette.ParseHex(o.Color); o.Color != "" && err != nil {
+		problems["color"] = "color must be a hex code such as ff8800"
+	}
+
+	if o.Sort != "" && !repo.FeedSort(o.Sort).Valid() {
+		problems["sort"] = "sort must be one of newest, top or trending"
+	}
+
+	if _, ok := helpers.Periods[o.Period]; o.Period != "" && !ok {
+		problems["period"] = "period must be one of day, week, month or all"
+	}
+
+	return problems
+}
+
+// Browse returns a page of the feed and the cursor to the next page, which is
+// empty once there is nothing left to fetch.
+func (g *Galleria) Browse(
+	ctx context.Context,
+	opts BrowseOptions,
+) (posts []models.Post, nextCursor string, err error) {
+	query := repo.FeedQuery{
+		Sort:     repo.FeedSort(opts.Sort),
+		Licenses: opts.Licenses,
+		ViewerID: opts.ViewerID,
+		Page:     opts.Page,
+	}
+
+	if opts.Tag != "" {
+		query.Tag, _ = tags.Normalize(opts.Tag)
+	}
+
+	if opts.Color != "" {
+		color, err := palette.ParseHex(opts.Color)
+		if err != nil {
+			return nil, "", err
+		}
+		query.Color = &color
+	}
+
+	if opts.Username != "" {
+		user, err := g.usersRepository.FindByUsername(ctx, opts.Username)
+		if err != nil {
+			return nil, "", ErrUserNotFound
+		}
+
+		// Blocked users cannot tell the account from one that does not exist.
+		blocked, err := g.isBlocked(ctx, user.ID, opts.ViewerID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if blocked {
+			return nil, "", ErrUserNotFound
+		}
+		query.UserID = user.ID
+	}
+
+	if query.Sort == "" {
+		query.Sort = repo.SortNewest
+	}
+
+	if query.Sort == repo.SortTop {
+		query.Within = helpers.Periods[opts.Period]
+	}
+
+	if opts.Cursor != "" {
+		cursor, err := repo.ParseCursor(opts.Cursor)
+		if err != nil || cursor.Kind != query.Sort.CursorKind() {
+			return nil, "", ErrInvalidCursor
+		}
+		query.After = cursor
+	}
+
+	posts, next, err := g.imagesRepository.Feed(ctx, query)
+	if err != nil || next == nil {
+		return posts, "", err
+	}
+
+	return posts, next.String(), nil
+}
+
+type SearchOptions struct {
+	Query string
+	// Language is the text search configuration the query is stemmed with,
+	// it defaults to english and does not filter the results.
+	Language string
+	// Licenses restricts the results to posts under any of them.
+	Licenses []string
+	Cursor   string
+	Page     uint64
+}
+
+func (o SearchOptions) Valid() (problems map[string]string) {
+	problems = make(map[string]string)
+
+	if strings.TrimSpace(o.Query) == "" || len(o.Query) > 200 {
+		problems["q"] = "query must be between 1 and 200 characters"
+	}
+
+	if o.Language != "" && !validLanguage(o.Language) {
+		problems["lang"] = "unsupported language"
+	}
+
+	if problem := validateLicenses(o.Licenses); problem != "" {
+		problems["license"] = problem
+	}
+
+	return problems
+}
+
+func validateLicenses(list []string) string {
+	for _, id := range list {
+		if !licenses.Valid(id) {
+			return "unknown license " + id
+		}
+	}
+
+	return ""
+}
+
+func (g *Galleria) Search(
+	ctx context.Context,
+	opts SearchOptions,
+) (results []models.SearchResult, nextCursor string, err error) {
+	query := repo.SearchQuery{
+		Text:     opts.Query,
+		Language: opts.Language,
+		Licenses: opts.Licenses,
+		Page:     opts.Page,
+	}
+
+	if query.Language == "" {
+		query.Language = "english"
+	}
+
+	if opts.Cursor != "" {
+		cursor, err := repo.ParseCursor(opts.Cursor)
+		if err != nil || cursor.Kind != repo.CursorSearch {
+			return nil, "", ErrInvalidCursor
+		}
+		query.After = cursor
+	}
+
+	results, next, err := g.imagesRepository.Search(ctx, query)
+	if err != nil || next == nil {
+		return results, "", err
+	}
+
+	return results, next.String(), nil
+}
+
 func (g *Galleria) SendImage(
 	ctx context.Context,
 	userId uuid.UUID,
 	req *SendImageRequest,
 ) (imageId uuid.UUID, err error) {
-	_, err = g.usersRepository.FindByID(ctx, userId)
+	user, err := g.usersRepository.FindByID(ctx, userId)
 	if err != nil {
 		return uuid.Nil, ErrUserNotFound
 	}
 
+	license := req.License
+	if license == "" {
+		license = user.DefaultLicense
+	}
+
+	status := req.Status
+	if status == "" {
+		status = "published"
+	}
+
 	image := &models.Image{
-		Title:       req.Title,
-		UserID:      userId,
-		Author:      req.Author,
-		Description: req.Description,
-		URL:         req.URL,
+		Title:        req.Title,
+		UserID:       userId,
+		Author:       req.Author,
+		Description:  req.Description,
+		URL:          req.URL,
+		Language:     req.Language,
+		Visibility:   req.Visibility,
+		Status:       status,
+		PublishAt:    utc(req.PublishAt),
+		License:      models.License{ID: license},
+		SourceURL:    req.SourceURL,
+		Tags:         imageTags(req.Tags, req.Description),
+		ExplicitTags: tags.Merge(req.Tags),
+		Mentions:     g.descriptionMentions(ctx, req.Description),
+	}
+
+	imageId, err = g.imagesRepository.Create(ctx, image)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// Users mentioned on a private post could not open it, the ones mentioned
+	// on drafts and scheduled posts are notified once it is published.
+	if announced(image) {
+		g.notifyMentions(ctx, userId, mentions.Users(image.Mentions), &imageId, nil)
+	}
+
+	return imageId, nil
+}
+
+// announced reports whether the users mentioned on the image should be told
+// about it.
+func announced(image *models.Image) bool {
+	return image.Status == "published" && image.Visibility != "private"
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	u := t.UTC()
+	return &u
+}
+
+func (g *Galleria) descriptionMentions(ctx context.Context, description *string) []models.Mention {
+	if description == nil {
+		return nil
 	}
 
-	return g.imagesRepository.Create(ctx, image)
+	return mentions.Resolve(ctx, g.usersRepository, *description)
+}
+
+// notifyMentions tells the users they were mentioned on the image, or on the
+// comment when commentID is set.
+func (g *Galleria) notifyMentions(
+	ctx context.Context,
+	actorID uuid.UUID,
+	users []uuid.UUID,
+	imageID, commentID *uuid.UUID,
+) {
+	for _, userID := range users {
+		notifications.Send(ctx, g.notificationsRepository, &repo.NotificationEvent{
+			UserID:    userID,
+			ActorID:   actorID,
+			Type:      repo.NotificationMention,
+			ImageID:   imageID,
+			CommentID: commentID,
+		})
+	}
+}
+
+// UpdateImage changes the fields set in req, only the owner of the image can
+// update it.
+func (g *Galleria) UpdateImage(
+	ctx context.Context,
+	userID, imageID uuid.UUID,
+	req *UpdateImageRequest,
+) error {
+	image, err := g.imagesRepository.FindByID(ctx, imageID, userID)
+	if err != nil {
+		return ErrImageNotFound
+	}
+
+	if image.UserID != userID {
+		return ErrNotImageOwner
+	}
+
+	wasAnnounced := announced(image)
+
+	if req.Title != nil {
+		image.Title = *req.Title
+	}
+
+	if req.Author != nil {
+		image.Author = req.Author
+	}
+
+	if req.Description != nil {
+		image.Description = req.Description
+	}
+
+	if req.URL != nil {
+		image.URL = *req.URL
+	}
+
+	if req.Language != nil {
+		image.Language = *req.Language
+	}
+
+	if req.Tags != nil {
+		image.ExplicitTags = *req.Tags
+	}
+
+	if req.Visibility != nil {
+		image.Visibility = *req.Visibility
+	}
+
+	if req.License != nil {
+		image.License = models.License{ID: *req.License}
+	}
+
+	if req.SourceURL != nil {
+		image.SourceURL = req.SourceURL
+		if *req.SourceURL == "" {
+			image.SourceURL = nil
+		}
+	}
+
+	publish := false
+	if req.Status != nil {
+		switch {
+		case image.Status == "published":
+			if *req.Status != "published" {
+				return ErrAlreadyPublished
+			}
+		case *req.Status == "published":
+			// The status is changed by Publish, after the other fields are saved.
+			publish = true
+		default:
+			image.Status = *req.Status
+			image.PublishAt = utc(req.PublishAt)
+		}
+	} else if req.PublishAt != nil {
+		if image.Status != "scheduled" {
+			return ErrNotScheduled
+		}
+		image.PublishAt = utc(req.PublishAt)
+	}
+
+	err = g.saveImage(ctx, image, req.Description != nil, wasAnnounced, repo.Edit{EditorID: userID})
+	if err != nil || !publish {
+		return err
+	}
+
+	if err := g.imagesRepository.Publish(ctx, image.ID); err != nil {
+		return err
+	}
+
+	image.Status = "published"
+	g.announce(ctx, image)
+
+	return nil
+}
+
+// announce notifies everyone mentioned on a post that was just published.
+func (g *Galleria) announce(ctx context.Context, image *models.Image) {
+	if announced(image) {
+		g.notifyMentions(ctx, image.UserID, mentions.Users(image.Mentions), &image.ID, nil)
+	}
+}
+
+// saveImage derives the tags from the explicit ones and the hashtags of the
+// description and, when the description changed, the mentions of the image
+// before storing it. Once the image is announced only users mentioned for
+// the first time are notified, when it was not announced before everyone
+// mentioned is, the way Publish does.
+func (g *Galleria) saveImage(
+	ctx context.Context,
+	image *models.Image,
+	descriptionChanged, wasAnnounced bool,
+	edit repo.Edit,
+) error {
+	image.ExplicitTags = tags.Merge(image.ExplicitTags)
+	image.Tags = imageTags(image.ExplicitTags, image.Description)
+	if len(image.Tags) > tags.MaxPerImage {
+		return ErrTooManyTags
+	}
+
+	var mentioned []uuid.UUID
+	if descriptionChanged {
+		previous := mentions.Users(image.Mentions)
+		image.Mentions = g.descriptionMentions(ctx, image.Description)
+		for _, userID := range mentions.Users(image.Mentions) {
+			if !slices.Contains(previous, userID) {
+				mentioned = append(mentioned, userID)
+			}
+		}
+	}
+
+	if err := g.imagesRepository.Update(ctx, image, edit); err != nil {
+		return err
+	}
+
+	if !announced(image) {
+		return nil
+	}
+
+	if !wasAnnounced {
+		mentioned = mentions.Users(image.Mentions)
+	}
+	g.notifyMentions(ctx, image.UserID, mentioned, &image.ID, nil)
+
+	return nil
+}
+
+// revisable returns the image if the user can see and revert its revisions,
+// which only its owner and moderators can.
+func (g *Galleria) revisable(ctx context.Context, userID, imageID uuid.UUID) (*models.Image, error) {
+	image, err := g.imagesRepository.GetImageByID(ctx, imageID)
+	if err != nil {
+		return nil, ErrImageNotFound
+	}
+
+	if image.UserID == userID {
+		return image, nil
+	}
+
+	user, err := g.usersRepository.FindByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if user.IsModerator {
+		return image, nil
+	}
+
+	// Other users are only told the post is not theirs if they can see it.
+	if _, err := g.imagesRepository.FindByID(ctx, imageID, userID); err != nil {
+		return nil, ErrImageNotFound
+	}
+
+	return nil, ErrNotImageOwner
+}
+
+// Revisions returns the edit history of the post, latest first.
+func (g *Galleria) Revisions(
+	ctx context.Context,
+	userID, imageID uuid.UUID,
+) ([]models.ImageRevision, error) {
+	if _, err := g.revisable(ctx, userID, imageID); err != nil {
+		return nil, err
+	}
+
+	return g.imagesRepository.Revisions(ctx, imageID)
+}
+
+// Revert sets the title, author, description and url of the post back to
+// those of the revision, which records a new revision.
+func (g *Galleria) Revert(ctx context.Context, userID, imageID uuid.UUID, number int) error {
+	image, err := g.revisable(ctx, userID, imageID)
+	if err != nil {
+		return err
+	}
+
+	revision, err := g.imagesRepository.FindRevision(ctx, imageID, number)
+	if err != nil {
+		return ErrRevisionNotFound
+	}
+
+	image.Title = revision.Title
+	image.Author = revision.Author
+	image.Description = revision.Description
+	image.URL = revision.URL
+
+	return g.saveImage(
+		ctx,
+		image,
+		true,
+		announced(image),
+		repo.Edit{EditorID: userID, RevertedFrom: &number},
+	)
 }
 
 func (g *Galleria) AddComment(
@@ -99,28 +782,190 @@ func (g *Galleria) AddComment(
 		return uuid.Nil, ErrUserNotFound
 	}
 
-	_, err = g.imagesRepository.FindByID(ctx, postId)
+	image, err := g.imagesRepository.FindByID(ctx, postId, userID)
 	if err != nil {
 		return uuid.Nil, ErrImageNotFound
 	}
 
+	blocked, err := g.isBlocked(ctx, image.UserID, userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if blocked {
+		return uuid.Nil, ErrBlocked
+	}
+
 	comment := &models.Comment{
-		UserID:  userID,
-		ImageID: postId,
-		Content: content,
+		UserID:   userID,
+		ImageID:  postId,
+		Content:  content,
+		Mentions: mentions.Resolve(ctx, g.usersRepository, content),
 	}
 
-	return g.commentsRepository.Create(ctx, comment)
+	commentID, err = g.commentsRepository.Create(ctx, comment)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// Only the owner can comment on a post that is not announced, the users
+	// they mention could not open it.
+	if announced(image) {
+		g.notifyMentions(ctx, userID, mentions.Users(comment.Mentions), &image.ID, &commentID)
+	}
+
+	notifications.Send(ctx, g.notificationsRepository, &repo.NotificationEvent{
+		UserID:  image.UserID,
+		ActorID: userID,
+		Type:    repo.NotificationComment,
+		ImageID: &image.ID,
+	})
+
+	return commentID, nil
 }
 
+// Reply adds a comment answering parentID, which must be a comment on the
+// post. Both the author of the parent comment and the owner of the post are
+// notified.
+func (g *Galleria) Reply(
+	ctx context.Context,
+	userID, postID, parentID uuid.UUID,
+	content string,
+) (commentID uuid.UUID, err error) {
+	_, err = g.usersRepository.FindByID(ctx, userID)
+	if err != nil {
+		return uuid.Nil, ErrUserNotFound
+	}
+
+	image, err := g.imagesRepository.FindByID(ctx, postID, userID)
+	if err != nil {
+		return uuid.Nil, ErrImageNotFound
+	}
+
+	blocked, err := g.isBlocked(ctx, image.UserID, userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if blocked {
+		return uuid.Nil, ErrBlocked
+	}
+
+	parent, err := g.commentsRepository.FindByID(ctx, parentID)
+	if err != nil || parent.ImageID != postID {
+		return uuid.Nil, ErrCommentNotFound
+	}
+
+	comment := &models.Comment{
+		UserID:   userID,
+		ImageID:  postID,
+		ParentID: &parent.ID,
+		Content:  content,
+		Mentions: mentions.Resolve(ctx, g.usersRepository, content),
+	}
+
+	commentID, err = g.commentsRepository.Create(ctx, comment)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if announced(image) {
+		g.notifyMentions(ctx, userID, mentions.Users(comment.Mentions), &image.ID, &commentID)
+	}
+
+	notifications.Send(ctx, g.notificationsRepository, &repo.NotificationEvent{
+		UserID:    parent.UserID,
+		ActorID:   userID,
+		Type:      repo.NotificationReply,
+		ImageID:   &image.ID,
+		CommentID: &parent.ID,
+	})
+
+	if image.UserID != parent.UserID {
+		notifications.Send(ctx, g.notificationsRepository, &repo.NotificationEvent{
+			UserID:  image.UserID,
+			ActorID: userID,
+			Type:    repo.NotificationComment,
+			ImageID: &image.ID,
+		})
+	}
+
+	return commentID, nil
+}
+
+// GetComments returns the comments on the image, leaving out those of the
+// accounts the viewer muted or blocked. viewerID is uuid.Nil for anonymous
+// visitors.
 func (g *Galleria) GetComments(
 	ctx context.Context,
-	imageID uuid.UUID,
+	viewerID, imageID uuid.UUID,
 ) ([]models.Comment, error) {
-	_, err := g.imagesRepository.FindByID(ctx, imageID)
+	_, err := g.imagesRepository.FindByID(ctx, imageID, viewerID)
 	if err != nil {
 		return nil, ErrImageNotFound
 	}
 
-	return g.commentsRepository.FindByImageID(ctx, imageID)
+	return g.commentsRepository.FindByImageID(ctx, imageID, viewerID)
+}
+
+// isBlocked tells whether ownerID blocked userID, anonymous visitors are never
+// blocked.
+func (g *Galleria) isBlocked(ctx context.Context, ownerID, userID uuid.UUID) (bool, error) {
+	if userID == uuid.Nil || ownerID == userID {
+		return false, nil
+	}
+
+	return g.blocksRepository.IsBlocked(ctx, ownerID, userID)
+}
+
+// GetImage returns the image if viewerID can see it, viewerID is uuid.Nil for
+// anonymous visitors.
+func (g *Galleria) GetImage(ctx context.Context, viewerID, imageID uuid.UUID) (*models.Image, error) {
+	image, err := g.imagesRepository.FindByID(ctx, imageID, viewerID)
+	if err != nil {
+		return nil, ErrImageNotFound
+	}
+
+	return image, nil
+}
+
+// GetComment returns the comment unless it is hidden from viewerID, the same
+// way GetComments leaves it out.
+func (g *Galleria) GetComment(ctx context.Context, viewerID, commentID uuid.UUID) (*models.Comment, error) {
+	comment, err := g.commentsRepository.FindVisible(ctx, commentID, viewerID)
+	if err != nil {
+		return nil, ErrCommentNotFound
+	}
+
+	return comment, nil
+}
+
+// streamReplayLimit caps how many missed events are replayed to a client
+// reconnecting to a stream.
+const streamReplayLimit = 100
+
+// CommentEventID identifies the comment in a stream, clients send it back as
+// Last-Event-ID when reconnecting.
+func CommentEventID(comment *models.Comment) string {
+	return repo.Cursor{
+		Kind:      repo.CursorComments,
+		CreatedAt: comment.CreatedAt.Time,
+		ID:        comment.ID,
+	}.String()
+}
+
+// CommentsAfter returns the comments on the post that came after the one
+// lastEventID identifies, oldest first. Comments hidden from viewerID are left
+// out.
+func (g *Galleria) CommentsAfter(
+	ctx context.Context,
+	viewerID, postID uuid.UUID,
+	lastEventID string,
+) ([]models.Comment, error) {
+	cursor, err := repo.ParseCursor(lastEventID)
+	if err != nil || cursor.Kind != repo.CursorComments {
+		return nil, ErrInvalidCursor
+	}
+
+	return g.commentsRepository.FindAfter(ctx, postID, viewerID, cursor, streamReplayLimit)
 }
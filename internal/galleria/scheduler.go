@@ -0,0 +1,63 @@
+package galleria
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// scheduleInterval is how often due posts are published, a post may go live
+// up to that late.
+const scheduleInterval = 30 * time.Second
+
+// PublishDue publishes the scheduled posts whose time has come, notifying the
+// users mentioned on them, and returns how many were published.
+func (g *Galleria) PublishDue(ctx context.Context) (int, error) {
+	imageIDs, err := g.imagesRepository.PublishDue(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, imageID := range imageIDs {
+		image, err := g.imagesRepository.GetImageByID(ctx, imageID)
+		if err != nil {
+			log.Printf("failed to announce post %s: %v", imageID, err)
+			continue
+		}
+
+		g.announce(ctx, image)
+	}
+
+	return len(imageIDs), nil
+}
+
+// Scheduler publishes scheduled posts once they are due.
+type Scheduler struct {
+	galleria *Galleria
+}
+
+func NewScheduler(galleria *Galleria) *Scheduler {
+	return &Scheduler{galleria}
+}
+
+// Run publishes the due posts once and then every 30 seconds until ctx is
+// done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		published, err := s.galleria.PublishDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("failed to publish scheduled posts: %v", err)
+		} else if published > 0 {
+			log.Printf("published %d scheduled posts", published)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
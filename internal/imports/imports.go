@@ -0,0 +1,173 @@
+package imports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/storage"
+	"github.com/google/uuid"
+)
+
+var ErrImportNotFound = errors.New("import not found")
+
+// Imports creates posts in bulk from manifests. Jobs are started by Start
+// and processed in the background by a Worker.
+type Imports struct {
+	importsRepository repo.ImportsRepository
+	galleria          *galleria.Galleria
+	files             *storage.Disk
+}
+
+func New(
+	importsRepository repo.ImportsRepository,
+	galleria *galleria.Galleria,
+	files *storage.Disk,
+) *Imports {
+	return &Imports{
+		importsRepository: importsRepository,
+		galleria:          galleria,
+		files:             files,
+	}
+}
+
+// uploadKey is where the manifest of a job is kept until it is processed.
+func uploadKey(job *models.ImportJob) string {
+	return "imports/" + job.ID.String() + "." + job.Format
+}
+
+// Start checks that the manifest can be read and queues it for import. Rows
+// are validated as they are processed, each gets its own result.
+func (i *Imports) Start(
+	ctx context.Context,
+	userID uuid.UUID,
+	format string,
+	data []byte,
+) (*models.ImportJob, error) {
+	manifest, err := Parse(format, data)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.ImportJob{
+		ID:     uuid.New(),
+		UserID: userID,
+		Format: format,
+		Total:  len(manifest.Rows),
+	}
+
+	if err := i.files.Put(uploadKey(job), bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	if err := i.importsRepository.Create(ctx, job); err != nil {
+		i.files.Remove(uploadKey(job))
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Get returns the progress and report of an import of the user.
+func (i *Imports) Get(ctx context.Context, userID, jobID uuid.UUID) (*models.ImportJob, error) {
+	job, err := i.importsRepository.FindByID(ctx, jobID)
+	if err != nil || job.UserID != userID {
+		return nil, ErrImportNotFound
+	}
+
+	return job, nil
+}
+
+// process imports the rows of the job not processed yet.
+func (i *Imports) process(ctx context.Context, job *models.ImportJob) error {
+	f, err := i.files.Open(uploadKey(job))
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := Parse(job.Format, data)
+	if err != nil {
+		return err
+	}
+
+	for n := job.Processed; n < len(manifest.Rows); n++ {
+		result := i.importRow(ctx, job.UserID, manifest, &manifest.Rows[n])
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		result.Row = n + 1
+		if err := i.importsRepository.AddResult(ctx, job.ID, result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i *Imports) importRow(
+	ctx context.Context,
+	userID uuid.UUID,
+	manifest *Manifest,
+	row *Row,
+) models.ImportResult {
+	var key string
+	if row.File != "" && manifest.files != nil && row.URL == "" {
+		key = path.Join(storage.ImagesDir, uuid.NewString()+strings.ToLower(path.Ext(row.File)))
+		row.URL = i.files.URL(key)
+	}
+
+	if problems := row.Problems(); len(problems) > 0 {
+		return models.ImportResult{Problems: problems}
+	}
+
+	if key != "" {
+		err := i.storeFile(manifest, row, key)
+		if errors.Is(err, errFileTooLarge) {
+			return models.ImportResult{Problems: map[string]string{"file": "file must be at most 20MB"}}
+		}
+
+		if err != nil {
+			log.Printf("failed to store imported file %s: %v", row.File, err)
+			return models.ImportResult{Error: "failed to store the file"}
+		}
+	}
+
+	imageID, err := i.galleria.SendImage(ctx, userID, &row.SendImageRequest)
+	if err != nil {
+		if key != "" {
+			i.files.Remove(key)
+		}
+
+		if !errors.Is(err, galleria.ErrUserNotFound) {
+			log.Printf("failed to import post: %v", err)
+		}
+
+		return models.ImportResult{Error: "failed to create the post"}
+	}
+
+	return models.ImportResult{ImageID: &imageID}
+}
+
+func (i *Imports) storeFile(manifest *Manifest, row *Row, key string) error {
+	r, err := manifest.Open(row)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return i.files.Put(key, r)
+}
@@ -0,0 +1,351 @@
+package imports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/edulustosa/galleria/internal/galleria"
+)
+
+const (
+	// MaxRows is how many posts a single manifest can import.
+	MaxRows = 1000
+	// maxFileBytes is the size limit of the images of a ZIP archive.
+	maxFileBytes = 20 << 20
+	// maxManifestBytes is the size limit of the manifest of a ZIP archive,
+	// which is read whatever its header claims.
+	maxManifestBytes = 10 << 20
+)
+
+// Formats are the accepted manifests. ZIP archives hold a manifest.csv or a
+// manifest.json along with the images the rows refer to.
+var Formats = []string{"csv", "json", "zip"}
+
+// FileExtensions are the images a ZIP archive can hold.
+var FileExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
+
+var ErrInvalidManifest = errors.New("invalid manifest")
+
+// Row is a post to import. File is the path of its image in the ZIP archive,
+// the url of the post is then where the image is stored.
+type Row struct {
+	galleria.SendImageRequest
+	File string `json:"file"`
+
+	// problems are the fields of the row that could not be read.
+	problems map[string]string
+}
+
+// Problems validates the row with galleria.SendImageRequest.Valid, along
+// with what went wrong reading it.
+func (r *Row) Problems() map[string]string {
+	problems := r.Valid()
+	for field, problem := range r.problems {
+		problems[field] = problem
+	}
+
+	return problems
+}
+
+// Manifest is a parsed manifest, files holds the images of ZIP archives.
+type Manifest struct {
+	Rows  []Row
+	files map[string]*zip.File
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidManifest, fmt.Sprintf(format, args...))
+}
+
+// Parse reads a manifest in one of the Formats.
+func Parse(format string, data []byte) (*Manifest, error) {
+	var (
+		manifest *Manifest
+		err      error
+	)
+
+	switch format {
+	case "csv":
+		manifest, err = parseCSV(bytes.NewReader(data))
+	case "json":
+		manifest, err = parseJSON(bytes.NewReader(data))
+	case "zip":
+		manifest, err = parseZIP(data)
+	default:
+		return nil, invalid("format must be one of csv, json or zip")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(manifest.Rows) == 0 {
+		return nil, invalid("manifest has no rows")
+	}
+
+	if len(manifest.Rows) > MaxRows {
+		return nil, invalid("manifest has more than %d rows", MaxRows)
+	}
+
+	if manifest.files == nil {
+		for i := range manifest.Rows {
+			if manifest.Rows[i].File != "" {
+				manifest.Rows[i].problem("file", "files can only be imported from a zip archive")
+			}
+		}
+	}
+
+	return manifest, nil
+}
+
+func (r *Row) problem(field, problem string) {
+	if r.problems == nil {
+		r.problems = make(map[string]string)
+	}
+
+	r.problems[field] = problem
+}
+
+func parseJSON(r io.Reader) (*Manifest, error) {
+	var rows []Row
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, invalid("manifest must be a json array of posts: %v", err)
+	}
+
+	return &Manifest{Rows: rows}, nil
+}
+
+// csvColumns are the columns a CSV manifest can have, named like the fields
+// of a JSON manifest. Tags are separated by spaces or commas.
+var csvColumns = []string{
+	"title",
+	"author",
+	"description",
+	"url",
+	"file",
+	"language",
+	"tags",
+	"visibility",
+	"license",
+	"sourceUrl",
+	"status",
+	"publishAt",
+}
+
+func parseCSV(r io.Reader) (*Manifest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 0
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, invalid("failed to read the header: %v", err)
+	}
+
+	for _, column := range header {
+		if !slices.Contains(csvColumns, column) {
+			return nil, invalid("unknown column %q", column)
+		}
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, invalid("%v", err)
+		}
+
+		if len(rows) == MaxRows {
+			return nil, invalid("manifest has more than %d rows", MaxRows)
+		}
+
+		var row Row
+		for i, value := range record {
+			row.set(header[i], value)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return &Manifest{Rows: rows}, nil
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
+
+func (r *Row) set(column, value string) {
+	switch column {
+	case "title":
+		r.Title = value
+	case "author":
+		r.Author = optional(value)
+	case "description":
+		r.Description = optional(value)
+	case "url":
+		r.URL = value
+	case "file":
+		r.File = value
+	case "language":
+		r.Language = value
+	case "tags":
+		r.Tags = strings.FieldsFunc(value, func(c rune) bool {
+			return c == ',' || unicode.IsSpace(c)
+		})
+	case "visibility":
+		r.Visibility = value
+	case "license":
+		r.License = value
+	case "sourceUrl":
+		r.SourceURL = optional(value)
+	case "status":
+		r.Status = value
+	case "publishAt":
+		if value == "" {
+			return
+		}
+
+		publishAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			r.problem("publishAt", "publish time must be an RFC 3339 timestamp")
+			return
+		}
+
+		r.PublishAt = &publishAt
+	}
+}
+
+func parseZIP(data []byte) (*Manifest, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, invalid("failed to open the archive: %v", err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		if !f.FileInfo().IsDir() {
+			files[path.Clean(f.Name)] = f
+		}
+	}
+
+	var manifest *Manifest
+	if f, ok := files["manifest.json"]; ok {
+		manifest, err = parseArchived(f, parseJSON)
+	} else if f, ok := files["manifest.csv"]; ok {
+		manifest, err = parseArchived(f, parseCSV)
+	} else {
+		return nil, invalid("archive has no manifest.json or manifest.csv")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	manifest.files = files
+	for i := range manifest.Rows {
+		row := &manifest.Rows[i]
+		if row.File == "" {
+			continue
+		}
+
+		if row.URL != "" {
+			row.problem("file", "rows can have a file or a url, not both")
+			continue
+		}
+
+		f, ok := files[path.Clean(row.File)]
+		switch {
+		case !ok:
+			row.problem("file", "file not found in the archive")
+		case !slices.Contains(FileExtensions, strings.ToLower(path.Ext(row.File))):
+			row.problem("file", "file must be a jpg, png, gif or webp image")
+		case f.UncompressedSize64 > maxFileBytes:
+			row.problem("file", "file must be at most 20MB")
+		}
+	}
+
+	return manifest, nil
+}
+
+func parseArchived(f *zip.File, parse func(io.Reader) (*Manifest, error)) (*Manifest, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, invalid("failed to open %s: %v", f.Name, err)
+	}
+	defer r.Close()
+
+	limited := &limitedReader{r: r, n: maxManifestBytes}
+	manifest, err := parse(limited)
+	if limited.exceeded {
+		return nil, invalid("%s is larger than 10MB", f.Name)
+	}
+
+	return manifest, err
+}
+
+// limitedReader reads up to n bytes and fails past them, rather than ending
+// early like io.LimitReader, so that oversized files are not silently cut.
+type limitedReader struct {
+	r        io.Reader
+	n        int64
+	exceeded bool
+}
+
+var errFileTooLarge = errors.New("file is larger than allowed")
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// Anything left past the limit means the file is too large.
+		var b [1]byte
+		if n, _ := l.r.Read(b[:]); n > 0 {
+			l.exceeded = true
+			return 0, errFileTooLarge
+		}
+
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// Open returns the image of a row from the ZIP archive. Reading fails once
+// past 20MB, whatever the header of the file claims.
+func (m *Manifest) Open(row *Row) (io.ReadCloser, error) {
+	f, ok := m.files[path.Clean(row.File)]
+	if !ok {
+		return nil, errors.New("file not found in the archive")
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{&limitedReader{r: r, n: maxFileBytes}, r}, nil
+}
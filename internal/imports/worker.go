@@ -0,0 +1,84 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// pollInterval is how often the worker looks for new jobs.
+	pollInterval = 10 * time.Second
+	// staleAfter is how long a running job can go without progress before
+	// another worker takes it over.
+	staleAfter = 5 * time.Minute
+)
+
+// Worker processes import jobs in the background, one at a time.
+type Worker struct {
+	imports *Imports
+}
+
+func NewWorker(imports *Imports) *Worker {
+	return &Worker{imports}
+}
+
+// ProcessNext processes the next job to the end and returns false when there
+// was none. A row interrupted mid way may be imported twice once resumed.
+func (w *Worker) ProcessNext(ctx context.Context) (bool, error) {
+	repository := w.imports.importsRepository
+
+	job, err := repository.Claim(ctx, staleAfter)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	var failure *string
+	if err := w.imports.process(ctx, job); err != nil {
+		if ctx.Err() != nil {
+			return true, ctx.Err()
+		}
+
+		log.Printf("failed to process import %s: %v", job.ID, err)
+		message := "failed to process the manifest"
+		failure = &message
+	}
+
+	if err := repository.Finish(ctx, job.ID, failure); err != nil {
+		return true, err
+	}
+
+	if err := w.imports.files.Remove(uploadKey(job)); err != nil {
+		log.Printf("failed to remove the manifest of import %s: %v", job.ID, err)
+	}
+
+	return true, nil
+}
+
+// Run processes jobs until ctx is done, checking for new ones every 10
+// seconds once it caught up.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		processed, err := w.ProcessNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("failed to process imports: %v", err)
+		}
+
+		if processed && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
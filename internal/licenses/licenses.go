@@ -0,0 +1,93 @@
+package licenses
+
+import (
+	"slices"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+)
+
+// Default is the license of posts whose owner did not pick one.
+const Default = "all-rights-reserved"
+
+// All are the licenses posts can be published under. Creative Commons ids are
+// their SPDX identifiers so clients can match them against other sources.
+var All = []models.License{
+	{
+		ID:   Default,
+		Name: "All rights reserved",
+	},
+	{
+		ID:          "public-domain",
+		Name:        "Public Domain Mark 1.0",
+		URL:         "https://creativecommons.org/publicdomain/mark/1.0/",
+		Commercial:  true,
+		Derivatives: true,
+	},
+	{
+		ID:          "CC0-1.0",
+		Name:        "CC0 1.0 Universal",
+		URL:         "https://creativecommons.org/publicdomain/zero/1.0/",
+		Commercial:  true,
+		Derivatives: true,
+	},
+	{
+		ID:          "CC-BY-4.0",
+		Name:        "Creative Commons Attribution 4.0",
+		URL:         "https://creativecommons.org/licenses/by/4.0/",
+		Attribution: true,
+		Commercial:  true,
+		Derivatives: true,
+	},
+	{
+		ID:          "CC-BY-SA-4.0",
+		Name:        "Creative Commons Attribution-ShareAlike 4.0",
+		URL:         "https://creativecommons.org/licenses/by-sa/4.0/",
+		Attribution: true,
+		Commercial:  true,
+		Derivatives: true,
+		ShareAlike:  true,
+	},
+	{
+		ID:          "CC-BY-ND-4.0",
+		Name:        "Creative Commons Attribution-NoDerivatives 4.0",
+		URL:         "https://creativecommons.org/licenses/by-nd/4.0/",
+		Attribution: true,
+		Commercial:  true,
+	},
+	{
+		ID:          "CC-BY-NC-4.0",
+		Name:        "Creative Commons Attribution-NonCommercial 4.0",
+		URL:         "https://creativecommons.org/licenses/by-nc/4.0/",
+		Attribution: true,
+		Derivatives: true,
+	},
+	{
+		ID:          "CC-BY-NC-SA-4.0",
+		Name:        "Creative Commons Attribution-NonCommercial-ShareAlike 4.0",
+		URL:         "https://creativecommons.org/licenses/by-nc-sa/4.0/",
+		Attribution: true,
+		Derivatives: true,
+		ShareAlike:  true,
+	},
+	{
+		ID:          "CC-BY-NC-ND-4.0",
+		Name:        "Creative Commons Attribution-NonCommercial-NoDerivatives 4.0",
+		URL:         "https://creativecommons.org/licenses/by-nc-nd/4.0/",
+		Attribution: true,
+	},
+}
+
+// Find returns the license with the id.
+func Find(id string) (models.License, bool) {
+	i := slices.IndexFunc(All, func(l models.License) bool { return l.ID == id })
+	if i < 0 {
+		return models.License{}, false
+	}
+
+	return All[i], true
+}
+
+func Valid(id string) bool {
+	_, ok := Find(id)
+	return ok
+}
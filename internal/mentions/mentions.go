@@ -0,0 +1,103 @@
+package mentions
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+// MaxPerText caps how many distinct users a single text can mention.
+const MaxPerText = 10
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@&])(@[\p{L}\p{N}_.]+)`)
+
+var usernamePattern = regexp.MustCompile(`^[\p{L}\p{N}_.]+$`)
+
+// ValidUsername reports whether username can be mentioned and used in urls:
+// letters, digits, underscores and dots, not at its ends.
+func ValidUsername(username string) bool {
+	return usernamePattern.MatchString(username) &&
+		!strings.HasPrefix(username, ".") && !strings.HasSuffix(username, ".")
+}
+
+// Find returns the @username spans in text, in order. Only Username, Start and
+// End are set, offsets are counted in characters and End is exclusive.
+func Find(text string) []models.Mention {
+	var found []models.Mention
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+
+		// A trailing dot ends the sentence, not the username.
+		for end > start+1 && text[end-1] == '.' {
+			end--
+		}
+		if end == start+1 {
+			continue
+		}
+
+		found = append(found, models.Mention{
+			Username: text[start+1 : end],
+			Start:    utf8.RuneCountInString(text[:start]),
+			End:      utf8.RuneCountInString(text[:end]),
+		})
+	}
+
+	return found
+}
+
+// Resolve returns the mentions in text of users that exist, up to MaxPerText
+// distinct users. Usernames are matched regardless of case and replaced by
+// the ones users registered with.
+func Resolve(
+	ctx context.Context,
+	usersRepository repo.UsersRepository,
+	text string,
+) []models.Mention {
+	users := make(map[string]*models.User)
+	resolved := make([]models.Mention, 0)
+
+	for _, mention := range Find(text) {
+		key := strings.ToLower(mention.Username)
+		user, seen := users[key]
+		if !seen {
+			if len(users) == MaxPerText {
+				break
+			}
+
+			found, err := usersRepository.FindByUsername(ctx, mention.Username)
+			if err == nil {
+				user = found
+			}
+			users[key] = user
+		}
+
+		if user == nil {
+			continue
+		}
+
+		mention.UserID = user.ID
+		mention.Username = user.Username
+		resolved = append(resolved, mention)
+	}
+
+	return resolved
+}
+
+// Users returns the distinct users mentioned, in order of first mention.
+func Users(mentions []models.Mention) []uuid.UUID {
+	var users []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, mention := range mentions {
+		if !seen[mention.UserID] {
+			seen[mention.UserID] = true
+			users = append(users, mention.UserID)
+		}
+	}
+
+	return users
+}
@@ -0,0 +1,239 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+type Moderation struct {
+	reportsRepository  repo.ReportsRepository
+	usersRepository    repo.UsersRepository
+	imagesRepository   repo.ImagesRepository
+	commentsRepository repo.CommentsRepository
+}
+
+func New(
+	reportsRepository repo.ReportsRepository,
+	usersRepository repo.UsersRepository,
+	imagesRepository repo.ImagesRepository,
+	commentsRepository repo.CommentsRepository,
+) *Moderation {
+	return &Moderation{
+		reportsRepository:  reportsRepository,
+		usersRepository:    usersRepository,
+		imagesRepository:   imagesRepository,
+		commentsRepository: commentsRepository,
+	}
+}
+
+var (
+	ErrTargetNotFound  = errors.New("reported content not found")
+	ErrReportNotFound  = errors.New("report not found")
+	ErrAlreadyReported = errors.New("you already reported this")
+	ErrNotModerator    = errors.New("only moderators can review reports")
+	ErrAlreadyResolved = errors.New("report is already closed")
+	ErrInvalidAction   = errors.New("action does not apply to the reported content")
+	ErrInvalidStatus   = errors.New("invalid report status")
+	ErrInvalidCursor   = errors.New("invalid cursor")
+)
+
+// Reasons is the taxonomy reports are filed under.
+var Reasons = []string{
+	"spam",
+	"harassment",
+	"hate",
+	"violence",
+	"nudity",
+	"copyright",
+	"other",
+}
+
+type ReportRequest struct {
+	Reason string `json:"reason"`
+	// Details are required when the reason is other.
+	Details *string `json:"details"`
+}
+
+func (r ReportRequest) Valid() (problems map[string]string) {
+	problems = make(map[string]string)
+
+	if !slices.Contains(Reasons, r.Reason) {
+		problems["reason"] = "reason must be one of " + strings.Join(Reasons, ", ")
+	}
+
+	if r.Details != nil && len(*r.Details) > 500 {
+		problems["details"] = "details must be less than 500 characters"
+	}
+
+	if r.Reason == "other" && (r.Details == nil || strings.TrimSpace(*r.Details) == "") {
+		problems["details"] = "details are required when the reason is other"
+	}
+
+	return problems
+}
+
+var actions = []repo.ModerationAction{
+	repo.ActionDismiss,
+	repo.ActionResolve,
+	repo.ActionHide,
+	repo.ActionRemove,
+}
+
+type ResolveRequest struct {
+	// Action is one of dismiss, resolve, hide or remove. Hide and remove only
+	// apply to posts and comments.
+	Action repo.ModerationAction `json:"action"`
+}
+
+func (r ResolveRequest) Valid() (problems map[string]string) {
+	problems = make(map[string]string)
+
+	if !slices.Contains(actions, r.Action) {
+		problems["action"] = "action must be one of dismiss, resolve, hide or remove"
+	}
+
+	return problems
+}
+
+// targetExists tells whether the reporter can see the target, content on
+// private posts of other users cannot be reported.
+func (m *Moderation) targetExists(
+	ctx context.Context,
+	reporterID uuid.UUID,
+	target repo.ReportTarget,
+	targetID uuid.UUID,
+) bool {
+	var err error
+	switch target {
+	case repo.ReportPost:
+		_, err = m.imagesRepository.FindByID(ctx, targetID, reporterID)
+	case repo.ReportComment:
+		var comment *models.Comment
+		comment, err = m.commentsRepository.FindByID(ctx, targetID)
+		if err == nil {
+			_, err = m.imagesRepository.FindByID(ctx, comment.ImageID, reporterID)
+		}
+	case repo.ReportUser:
+		_, err = m.usersRepository.FindByID(ctx, targetID)
+	default:
+		return false
+	}
+
+	return err == nil
+}
+
+// Report files a report on the target for moderators to review.
+func (m *Moderation) Report(
+	ctx context.Context,
+	reporterID uuid.UUID,
+	target repo.ReportTarget,
+	targetID uuid.UUID,
+	r *ReportRequest,
+) error {
+	if !m.targetExists(ctx, reporterID, target, targetID) {
+		return ErrTargetNotFound
+	}
+
+	created, err := m.reportsRepository.Create(ctx, &models.Report{
+		ReporterID: reporterID,
+		TargetType: string(target),
+		TargetID:   targetID,
+		Reason:     r.Reason,
+		Details:    r.Details,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !created {
+		return ErrAlreadyReported
+	}
+
+	return nil
+}
+
+func (m *Moderation) checkModerator(ctx context.Context, userID uuid.UUID) error {
+	user, err := m.usersRepository.FindByID(ctx, userID)
+	if err != nil || !user.IsModerator {
+		return ErrNotModerator
+	}
+
+	return nil
+}
+
+// Queue returns a page of the reports with the status, oldest first, and the
+// cursor to the next page. Status defaults to open.
+func (m *Moderation) Queue(
+	ctx context.Context,
+	moderatorID uuid.UUID,
+	status string,
+	cursor string,
+) (reports []models.Report, nextCursor string, err error) {
+	if err := m.checkModerator(ctx, moderatorID); err != nil {
+		return nil, "", err
+	}
+
+	reportStatus := repo.ReportStatus(status)
+	switch reportStatus {
+	case "":
+		reportStatus = repo.ReportOpen
+	case repo.ReportOpen, repo.ReportResolved, repo.ReportDismissed:
+	default:
+		return nil, "", ErrInvalidStatus
+	}
+
+	var after *repo.Cursor
+	if cursor != "" {
+		after, err = repo.ParseCursor(cursor)
+		if err != nil || after.Kind != repo.CursorReports {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+
+	reports, next, err := m.reportsRepository.FindByStatus(ctx, reportStatus, after)
+	if err != nil || next == nil {
+		return reports, "", err
+	}
+
+	return reports, next.String(), nil
+}
+
+// Resolve applies the action to the reported content and closes every open
+// report on it, not only the one reviewed.
+func (m *Moderation) Resolve(
+	ctx context.Context,
+	moderatorID uuid.UUID,
+	reportID uuid.UUID,
+	r *ResolveRequest,
+) error {
+	if err := m.checkModerator(ctx, moderatorID); err != nil {
+		return err
+	}
+
+	report, err := m.reportsRepository.FindByID(ctx, reportID)
+	if err != nil {
+		return ErrReportNotFound
+	}
+
+	if report.Status != string(repo.ReportOpen) {
+		return ErrAlreadyResolved
+	}
+
+	target := repo.ReportTarget(report.TargetType)
+	if target == repo.ReportUser && (r.Action == repo.ActionHide || r.Action == repo.ActionRemove) {
+		return ErrInvalidAction
+	}
+
+	return m.reportsRepository.Resolve(ctx, &repo.Resolution{
+		ModeratorID: moderatorID,
+		TargetType:  target,
+		TargetID:    report.TargetID,
+		Action:      r.Action,
+	})
+}
@@ -0,0 +1,129 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+type Notifications struct {
+	notificationsRepository repo.NotificationsRepository
+}
+
+func New(notificationsRepository repo.NotificationsRepository) *Notifications {
+	return &Notifications{notificationsRepository}
+}
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+)
+
+// Send records the event. Notifications are a side effect, failing to send
+// one is logged and must not fail the action that caused it.
+func Send(
+	ctx context.Context,
+	notificationsRepository repo.NotificationsRepository,
+	event *repo.NotificationEvent,
+) {
+	if err := notificationsRepository.Create(ctx, event); err != nil {
+		log.Printf("failed to send %s notification: %v", event.Type, err)
+	}
+}
+
+// Inbox is a page of the notifications of a user.
+type Inbox struct {
+	Notifications []models.Notification
+	Unread        int64
+	NextCursor    string
+}
+
+func (n *Notifications) List(ctx context.Context, userID uuid.UUID, cursor string) (*Inbox, error) {
+	var after *repo.Cursor
+	if cursor != "" {
+		parsed, err := repo.ParseCursor(cursor)
+		if err != nil || parsed.Kind != repo.CursorNotifications {
+			return nil, ErrInvalidCursor
+		}
+		after = parsed
+	}
+
+	notifications, next, err := n.notificationsRepository.FindByUserID(ctx, userID, after)
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := n.notificationsRepository.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	inbox := &Inbox{Notifications: notifications, Unread: unread}
+	if next != nil {
+		inbox.NextCursor = next.String()
+	}
+
+	return inbox, nil
+}
+
+func (n *Notifications) MarkRead(ctx context.Context, userID, notificationID uuid.UUID) error {
+	found, err := n.notificationsRepository.MarkRead(ctx, userID, notificationID)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+func (n *Notifications) MarkAllRead(ctx context.Context, userID uuid.UUID) error {
+	return n.notificationsRepository.MarkAllRead(ctx, userID)
+}
+
+func (n *Notifications) Get(
+	ctx context.Context,
+	userID, notificationID uuid.UUID,
+) (*models.Notification, error) {
+	notification, err := n.notificationsRepository.FindByID(ctx, userID, notificationID)
+	if err != nil {
+		return nil, ErrNotificationNotFound
+	}
+
+	return notification, nil
+}
+
+// streamReplayLimit caps how many missed events are replayed to a client
+// reconnecting to the stream.
+const streamReplayLimit = 100
+
+// EventID identifies the notification in a stream, it changes every time the
+// notification does. Clients send it back as Last-Event-ID when reconnecting.
+func EventID(notification *models.Notification) string {
+	return repo.Cursor{
+		Kind:      repo.CursorNotifications,
+		CreatedAt: notification.UpdatedAt.Time,
+		ID:        notification.ID,
+	}.String()
+}
+
+// After returns the notifications of the user that changed after the event
+// lastEventID identifies, oldest first.
+func (n *Notifications) After(
+	ctx context.Context,
+	userID uuid.UUID,
+	lastEventID string,
+) ([]models.Notification, error) {
+	cursor, err := repo.ParseCursor(lastEventID)
+	if err != nil || cursor.Kind != repo.CursorNotifications {
+		return nil, ErrInvalidCursor
+	}
+
+	return n.notificationsRepository.FindAfter(ctx, userID, cursor, streamReplayLimit)
+}
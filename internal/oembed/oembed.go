@@ -0,0 +1,174 @@
+package oembed
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+const (
+	// ThumbnailSize bounds the width and height of thumbnails.
+	ThumbnailSize = 320
+	// CacheAge is how long consumers may cache responses, in seconds.
+	CacheAge = 3600
+)
+
+var (
+	ErrInvalidURL   = errors.New("url is not a post of this server")
+	ErrPostNotFound = errors.New("post not found")
+)
+
+// Response is an oEmbed response. Posts whose dimensions are known are
+// photos, the others are links, which need none.
+type Response struct {
+	Type            string `json:"type"`
+	Version         string `json:"version"`
+	Title           string `json:"title,omitempty"`
+	AuthorName      string `json:"author_name,omitempty"`
+	AuthorURL       string `json:"author_url,omitempty"`
+	ProviderName    string `json:"provider_name"`
+	ProviderURL     string `json:"provider_url"`
+	CacheAge        int    `json:"cache_age"`
+	URL             string `json:"url,omitempty"`
+	Width           int    `json:"width,omitempty"`
+	Height          int    `json:"height,omitempty"`
+	ThumbnailURL    string `json:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty"`
+}
+
+// Request is what a consumer asks for, MaxWidth and MaxHeight are zero when
+// not set.
+type Request struct {
+	URL       string
+	MaxWidth  int
+	MaxHeight int
+}
+
+type OEmbed struct {
+	imagesRepository repo.ImagesRepository
+	usersRepository  repo.UsersRepository
+	publicURL        string
+}
+
+// New resolves the urls of posts under publicURL, the address the server is
+// reached at.
+func New(
+	imagesRepository repo.ImagesRepository,
+	usersRepository repo.UsersRepository,
+	publicURL string,
+) *OEmbed {
+	return &OEmbed{
+		imagesRepository: imagesRepository,
+		usersRepository:  usersRepository,
+		publicURL:        strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+// postID reads the id of the post from a url like
+// https://galleria.example/galleria/posts/{postId}.
+func (o *OEmbed) postID(raw string) (uuid.UUID, error) {
+	postURL, err := url.Parse(raw)
+	if err != nil {
+		return uuid.Nil, ErrInvalidURL
+	}
+
+	public, err := url.Parse(o.publicURL)
+	if err != nil || !strings.EqualFold(postURL.Host, public.Host) ||
+		(postURL.Scheme != "http" && postURL.Scheme != "https") {
+		return uuid.Nil, ErrInvalidURL
+	}
+
+	id, ok := strings.CutPrefix(strings.TrimSuffix(postURL.Path, "/"), public.Path+"/galleria/posts/")
+	if !ok {
+		return uuid.Nil, ErrInvalidURL
+	}
+
+	postID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, ErrInvalidURL
+	}
+
+	return postID, nil
+}
+
+// fit scales width and height down to fit within maxWidth and maxHeight,
+// keeping the aspect ratio. Zero bounds are ignored.
+func fit(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+
+	if maxHeight > 0 && float64(height)*scale > float64(maxHeight) {
+		scale = float64(maxHeight) / float64(height)
+	}
+
+	return max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale))
+}
+
+// Resolve describes the post at the url, as seen by someone signed out.
+// Private posts, drafts, deleted posts and the ones hidden by moderators are
+// not found.
+func (o *OEmbed) Resolve(ctx context.Context, req Request) (*Response, error) {
+	postID, err := o.postID(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := o.imagesRepository.FindShared(ctx, postID)
+	if err != nil {
+		return nil, ErrPostNotFound
+	}
+
+	owner, err := o.usersRepository.FindByID(ctx, image.UserID)
+	if err != nil {
+		return nil, ErrPostNotFound
+	}
+
+	res := &Response{
+		Type:         "link",
+		Version:      "1.0",
+		Title:        image.Title,
+		AuthorName:   owner.Username,
+		AuthorURL:    o.publicURL + "/users/" + url.PathEscape(owner.Username),
+		ProviderName: "Galleria",
+		ProviderURL:  o.publicURL,
+		CacheAge:     CacheAge,
+	}
+
+	// The credited author of the work may not be the one who posted it.
+	if image.Author != nil && *image.Author != "" && *image.Author != owner.Username {
+		res.AuthorName = *image.Author
+		res.AuthorURL = ""
+	}
+
+	if hasDimensions(image) {
+		res.Type = "photo"
+		res.URL = image.URL
+		res.Width, res.Height = fit(*image.Width, *image.Height, req.MaxWidth, req.MaxHeight)
+
+		thumbnailWidth, thumbnailHeight := ThumbnailSize, ThumbnailSize
+		if req.MaxWidth > 0 {
+			thumbnailWidth = min(thumbnailWidth, req.MaxWidth)
+		}
+
+		if req.MaxHeight > 0 {
+			thumbnailHeight = min(thumbnailHeight, req.MaxHeight)
+		}
+
+		res.ThumbnailURL = image.URL
+		res.ThumbnailWidth, res.ThumbnailHeight = fit(*image.Width, *image.Height, thumbnailWidth, thumbnailHeight)
+	}
+
+	return res, nil
+}
+
+func hasDimensions(image *models.Image) bool {
+	return image.Width != nil && image.Height != nil && *image.Width > 0 && *image.Height > 0
+}
@@ -0,0 +1,190 @@
+package palette
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+)
+
+const (
+	// Size is how many colors a palette holds at most.
+	Size = 5
+	// maxSamples is about how many pixels are read, larger images are sampled
+	// on a grid.
+	maxSamples = 100_000
+	// mergeDistance is how close in Lab two colors must be to count as one.
+	mergeDistance = 12
+	// minWeight is the share of the image a color must cover to be dominant.
+	minWeight = 0.03
+)
+
+var ErrInvalidColor = errors.New("invalid color")
+
+// ParseHex parses a color written as rrggbb, with or without a leading #.
+func ParseHex(hex string) (repo.Lab, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return repo.Lab{}, ErrInvalidColor
+	}
+
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return repo.Lab{}, ErrInvalidColor
+	}
+
+	return ToLab(float64(rgb>>16&0xff), float64(rgb>>8&0xff), float64(rgb&0xff)), nil
+}
+
+// linear undoes the gamma of an sRGB channel in the 0-255 range.
+func linear(c float64) float64 {
+	c /= 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func labF(t float64) float64 {
+	if t > 216.0/24389 {
+		return math.Cbrt(t)
+	}
+
+	return t*24389/27/116 + 16.0/116
+}
+
+// ToLab converts an sRGB color, channels in the 0-255 range, to Lab under the
+// D65 illuminant.
+func ToLab(r, g, b float64) repo.Lab {
+	r, g, b = linear(r), linear(g), linear(b)
+
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return repo.Lab{
+		L: 116*fy - 16,
+		A: 500 * (fx - fy),
+		B: 200 * (fy - fz),
+	}
+}
+
+// Distance is the CIE76 color difference of two colors.
+func Distance(c1, c2 repo.Lab) float64 {
+	return math.Sqrt(
+		(c1.L-c2.L)*(c1.L-c2.L) +
+			(c1.A-c2.A)*(c1.A-c2.A) +
+			(c1.B-c2.B)*(c1.B-c2.B),
+	)
+}
+
+// cluster accumulates the pixels of similar colors.
+type cluster struct {
+	r, g, b float64
+	count   float64
+	lab     repo.Lab
+}
+
+func (c *cluster) add(r, g, b, count float64) {
+	c.r += r
+	c.g += g
+	c.b += b
+	c.count += count
+	c.lab = ToLab(c.r/c.count, c.g/c.count, c.b/c.count)
+}
+
+// Extract returns up to Size dominant colors of the image, most dominant
+// first. Pixels are bucketed by their 4 most significant bits per channel,
+// then buckets are merged from the most to the least populated into the first
+// cluster close enough in Lab. Transparent pixels are ignored.
+func Extract(img image.Image) []repo.PaletteColor {
+	bounds := img.Bounds()
+	step := 1
+	if pixels := bounds.Dx() * bounds.Dy(); pixels > maxSamples {
+		step = int(math.Ceil(math.Sqrt(float64(pixels) / maxSamples)))
+	}
+
+	var buckets [4096]cluster
+	var total float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < 0x8000 {
+				continue
+			}
+
+			r, g, b = r>>8, g>>8, b>>8
+			bucket := &buckets[r>>4<<8|g>>4<<4|b>>4]
+			bucket.r += float64(r)
+			bucket.g += float64(g)
+			bucket.b += float64(b)
+			bucket.count++
+			total++
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	populated := make([]cluster, 0, len(buckets))
+	for _, bucket := range buckets {
+		if bucket.count > 0 {
+			populated = append(populated, bucket)
+		}
+	}
+	sort.Slice(populated, func(i, j int) bool {
+		return populated[i].count > populated[j].count
+	})
+
+	var clusters []*cluster
+	for _, bucket := range populated {
+		lab := ToLab(bucket.r/bucket.count, bucket.g/bucket.count, bucket.b/bucket.count)
+
+		var closest *cluster
+		for _, c := range clusters {
+			if Distance(c.lab, lab) < mergeDistance {
+				closest = c
+				break
+			}
+		}
+
+		if closest == nil {
+			closest = &cluster{}
+			clusters = append(clusters, closest)
+		}
+		closest.add(bucket.r, bucket.g, bucket.b, bucket.count)
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].count > clusters[j].count
+	})
+
+	colors := make([]repo.PaletteColor, 0, Size)
+	for _, c := range clusters {
+		weight := c.count / total
+		if len(colors) == Size || weight < minWeight {
+			break
+		}
+
+		colors = append(colors, repo.PaletteColor{
+			Hex: fmt.Sprintf(
+				"#%02x%02x%02x",
+				uint8(math.Round(c.r/c.count)),
+				uint8(math.Round(c.g/c.count)),
+				uint8(math.Round(c.b/c.count)),
+			),
+			Lab:    c.lab,
+			Weight: weight,
+		})
+	}
+
+	return colors
+}
@@ -0,0 +1,158 @@
+package palette
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/edulustosa/galleria/helpers"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/storage"
+)
+
+const (
+	// pollInterval is how often the worker looks for new images.
+	pollInterval = 30 * time.Second
+	batchSize    = 20
+	fetchTimeout = 15 * time.Second
+	// maxImageBytes and maxImagePixels keep huge or malicious files from
+	// exhausting the memory of the server.
+	maxImageBytes  = 20 << 20
+	maxImagePixels = 50_000_000
+)
+
+// Worker extracts the palettes of new and replaced images in the background,
+// images stored on files are read from disk and the others fetched from their
+// url.
+type Worker struct {
+	palettesRepository repo.PalettesRepository
+	files              *storage.Disk
+	client             *http.Client
+}
+
+func NewWorker(palettesRepository repo.PalettesRepository, files *storage.Disk) *Worker {
+	return &Worker{
+		palettesRepository: palettesRepository,
+		files:              files,
+		client:             helpers.PublicClient(fetchTimeout),
+	}
+}
+
+// open reads the image at url from files when it is stored there, from the
+// network otherwise.
+func (w *Worker) open(ctx context.Context, url string) (io.ReadCloser, error) {
+	if key, ok := w.files.Key(url); ok {
+		return w.files.Open(key)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+func (w *Worker) fetch(ctx context.Context, url string) (image.Image, error) {
+	body, err := w.open(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxImageBytes {
+		return nil, errors.New("image is too large")
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Width*config.Height > maxImagePixels {
+		return nil, errors.New("image has too many pixels")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// ProcessPending extracts the palettes and dimensions of a batch of pending
+// images and returns how many were processed. Images that cannot be fetched
+// or decoded get an empty palette.
+func (w *Worker) ProcessPending(ctx context.Context) (int, error) {
+	images, err := w.palettesRepository.FindPending(ctx, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, pending := range images {
+		var (
+			colors        []repo.PaletteColor
+			width, height int
+		)
+
+		img, err := w.fetch(ctx, pending.URL)
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+
+		if err != nil {
+			log.Printf("failed to extract the palette of image %s: %v", pending.ID, err)
+		} else {
+			colors = Extract(img)
+			width, height = img.Bounds().Dx(), img.Bounds().Dy()
+		}
+
+		if err := w.palettesRepository.SetPalette(ctx, pending, colors, width, height); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(images), nil
+}
+
+// Run processes pending images until ctx is done, checking for new ones every
+// 30 seconds once it caught up.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		processed, err := w.ProcessPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("failed to process palettes: %v", err)
+		}
+
+		if processed == batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
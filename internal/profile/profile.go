@@ -7,21 +7,29 @@ import (
 
 	"github.com/edulustosa/galleria/internal/database/models"
 	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/licenses"
+	"github.com/edulustosa/galleria/internal/mentions"
 	"github.com/google/uuid"
 )
 
 type Profile struct {
-	usersRepository  repo.UsersRepository
-	imagesRepository repo.ImagesRepository
+	usersRepository   repo.UsersRepository
+	imagesRepository  repo.ImagesRepository
+	followsRepository repo.FollowsRepository
+	blocksRepository  repo.BlocksRepository
 }
 
 func New(
 	usersRepository repo.UsersRepository,
 	imagesRepository repo.ImagesRepository,
+	followsRepository repo.FollowsRepository,
+	blocksRepository repo.BlocksRepository,
 ) *Profile {
 	return &Profile{
 		usersRepository,
 		imagesRepository,
+		followsRepository,
+		blocksRepository,
 	}
 }
 
@@ -29,6 +37,8 @@ type UpdateProfileRequest struct {
 	Username          *string `json:"username"`
 	Bio               *string `json:"bio"`
 	ProfilePictureURL *string `json:"avatar"`
+	// DefaultLicense is the license new posts get when none is picked.
+	DefaultLicense *string `json:"defaultLicense"`
 }
 
 func (r UpdateProfileRequest) Valid() (problems map[string]string) {
@@ -36,12 +46,18 @@ func (r UpdateProfileRequest) Valid() (problems map[string]string) {
 
 	if r.Username != nil && (len(*r.Username) < 3 || len(*r.Username) > 32) {
 		problems["username"] = "must be between 3 and 32 characters long"
+	} else if r.Username != nil && !mentions.ValidUsername(*r.Username) {
+		problems["username"] = "can only have letters, digits, underscores and dots, not at its ends"
 	}
 
 	if r.Bio != nil && len(*r.Bio) > 500 {
 		problems["bio"] = "must be between 8 and 500 characters long"
 	}
 
+	if r.DefaultLicense != nil && !licenses.Valid(*r.DefaultLicense) {
+		problems["defaultLicense"] = "unknown license"
+	}
+
 	if r.ProfilePictureURL != nil {
 		parsedURL, err := url.Parse(*r.ProfilePictureURL)
 		if err != nil {
@@ -54,7 +70,11 @@ func (r UpdateProfileRequest) Valid() (problems map[string]string) {
 	return problems
 }
 
-var ErrInvalidCredentials = errors.New("invalid credentials")
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUsernameTaken      = errors.New("username is already taken")
+)
 
 func (p *Profile) Update(
 	ctx context.Context,
@@ -67,6 +87,10 @@ func (p *Profile) Update(
 	}
 
 	if req.Username != nil {
+		owner, err := p.usersRepository.FindByUsername(ctx, *req.Username)
+		if err == nil && owner.ID != user.ID {
+			return ErrUsernameTaken
+		}
 		user.Username = *req.Username
 	}
 
@@ -78,7 +102,16 @@ func (p *Profile) Update(
 		user.ProfilePictureURL = req.ProfilePictureURL
 	}
 
-	return p.usersRepository.Update(ctx, user)
+	if req.DefaultLicense != nil {
+		user.DefaultLicense = *req.DefaultLicense
+	}
+
+	err = p.usersRepository.Update(ctx, user)
+	if errors.Is(err, repo.ErrUsernameTaken) {
+		return ErrUsernameTaken
+	}
+
+	return err
 }
 
 func (p *Profile) GetProfile(ctx context.Context, id uuid.UUID) (*models.User, error) {
@@ -98,3 +131,43 @@ func (p *Profile) GetProfileImages(ctx context.Context, id uuid.UUID) ([]models.
 
 	return images, nil
 }
+
+// GetPublicProfile returns what anyone can see of the user with the username.
+// viewerID is uuid.Nil for anonymous visitors, users the account blocked get
+// ErrUserNotFound.
+func (p *Profile) GetPublicProfile(
+	ctx context.Context,
+	viewerID uuid.UUID,
+	username string,
+) (*models.PublicProfile, error) {
+	user, err := p.usersRepository.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if viewerID != uuid.Nil && viewerID != user.ID {
+		blocked, err := p.blocksRepository.IsBlocked(ctx, user.ID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+
+		if blocked {
+			return nil, ErrUserNotFound
+		}
+	}
+
+	counts, err := p.followsRepository.Counts(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PublicProfile{
+		ID:        user.ID,
+		Username:  user.Username,
+		Bio:       user.Bio,
+		Avatar:    user.ProfilePictureURL,
+		CreatedAt: user.CreatedAt,
+		Followers: counts.Followers,
+		Following: counts.Following,
+	}, nil
+}
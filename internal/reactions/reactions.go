@@ -0,0 +1,153 @@
+package reactions
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/notifications"
+	"github.com/google/uuid"
+)
+
+type Reactions struct {
+	imagesRepository        repo.ImagesRepository
+	commentsRepository      repo.CommentsRepository
+	reactionsRepository     repo.ReactionsRepository
+	notificationsRepository repo.NotificationsRepository
+}
+
+func New(
+	imagesRepository repo.ImagesRepository,
+	commentsRepository repo.CommentsRepository,
+	reactionsRepository repo.ReactionsRepository,
+	notificationsRepository repo.NotificationsRepository,
+) *Reactions {
+	return &Reactions{
+		imagesRepository:        imagesRepository,
+		commentsRepository:      commentsRepository,
+		reactionsRepository:     reactionsRepository,
+		notificationsRepository: notificationsRepository,
+	}
+}
+
+// Like is the reaction that stands in for a classic "like".
+const Like = "❤️"
+
+// Allowed is the fixed set of emoji users can react with.
+var Allowed = []string{Like, "👍", "😂", "😮", "😢", "🔥", "👏"}
+
+var (
+	ErrInvalidEmoji    = errors.New("emoji is not allowed")
+	ErrImageNotFound   = errors.New("image not found")
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+const variationSelector16 = "\uFE0F"
+
+// Normalize returns the canonical form of emoji from the allowlist, clients
+// are not consistent about sending the emoji variation selector.
+func Normalize(emoji string) (string, error) {
+	stripped := strings.ReplaceAll(emoji, variationSelector16, "")
+	for _, allowed := range Allowed {
+		if strings.ReplaceAll(allowed, variationSelector16, "") == stripped {
+			return allowed, nil
+		}
+	}
+
+	return "", ErrInvalidEmoji
+}
+
+func (r *Reactions) ReactToImage(
+	ctx context.Context,
+	userID, imageID uuid.UUID,
+	emoji string,
+) error {
+	emoji, err := Normalize(emoji)
+	if err != nil {
+		return err
+	}
+
+	image, err := r.imagesRepository.FindByID(ctx, imageID, userID)
+	if err != nil {
+		return ErrImageNotFound
+	}
+
+	if err := r.reactionsRepository.AddToImage(ctx, userID, imageID, emoji); err != nil {
+		return err
+	}
+
+	if emoji == Like {
+		notifications.Send(ctx, r.notificationsRepository, &repo.NotificationEvent{
+			UserID:  image.UserID,
+			ActorID: userID,
+			Type:    repo.NotificationLike,
+			ImageID: &image.ID,
+		})
+	}
+
+	return nil
+}
+
+func (r *Reactions) UnreactToImage(
+	ctx context.Context,
+	userID, imageID uuid.UUID,
+	emoji string,
+) error {
+	emoji, err := Normalize(emoji)
+	if err != nil {
+		return err
+	}
+
+	return r.reactionsRepository.RemoveFromImage(ctx, userID, imageID, emoji)
+}
+
+func (r *Reactions) ReactToComment(
+	ctx context.Context,
+	userID, commentID uuid.UUID,
+	emoji string,
+) error {
+	emoji, err := Normalize(emoji)
+	if err != nil {
+		return err
+	}
+
+	// Comments hidden by moderators cannot be reacted to.
+	comment, err := r.commentsRepository.FindVisible(ctx, commentID, userID)
+	if err != nil {
+		return ErrCommentNotFound
+	}
+
+	if _, err := r.imagesRepository.FindByID(ctx, comment.ImageID, userID); err != nil {
+		return ErrCommentNotFound
+	}
+
+	if err := r.reactionsRepository.AddToComment(ctx, userID, commentID, emoji); err != nil {
+		return err
+	}
+
+	if emoji == Like {
+		notifications.Send(ctx, r.notificationsRepository, &repo.NotificationEvent{
+			UserID:    comment.UserID,
+			ActorID:   userID,
+			Type:      repo.NotificationLike,
+			ImageID:   &comment.ImageID,
+			CommentID: &comment.ID,
+		})
+	}
+
+	return nil
+}
+
+func (r *Reactions) UnreactToComment(
+	ctx context.Context,
+	userID, commentID uuid.UUID,
+	emoji string,
+) error {
+	emoji, err := Normalize(emoji)
+	if err != nil {
+		return err
+	}
+
+	return r.reactionsRepository.RemoveFromComment(ctx, userID, commentID, emoji)
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ImagesDir holds the files uploaded as posts, the only ones served publicly.
+const ImagesDir = "images"
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Disk keeps files in a directory of the server. Keys are slash separated
+// paths relative to it.
+type Disk struct {
+	dir     string
+	baseURL string
+}
+
+// New stores files under dir, baseURL is the public address of the server
+// the files under ImagesDir are served from.
+func New(dir, baseURL string) *Disk {
+	return &Disk{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (d *Disk) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(d.dir, filepath.FromSlash(clean)), nil
+}
+
+// Put writes what r reads to key, replacing it once fully written.
+func (d *Disk) Put(key string, r io.Reader) error {
+	name, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+func (d *Disk) Open(key string) (*os.File, error) {
+	name, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(name)
+}
+
+// Remove deletes key, removing a missing key is not an error.
+func (d *Disk) Remove(key string) error {
+	name, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// URL is the public address of a key under ImagesDir.
+func (d *Disk) URL(key string) string {
+	return d.baseURL + "/files/" + key
+}
+
+// Key returns the key of a url returned by URL, ok is false for urls of
+// other servers.
+func (d *Disk) Key(url string) (key string, ok bool) {
+	key, ok = strings.CutPrefix(url, d.baseURL+"/files/")
+	if !ok || !strings.HasPrefix(key, ImagesDir+"/") {
+		return "", false
+	}
+
+	return key, true
+}
+
+// PublicHandler serves the files under ImagesDir, it is meant to be mounted
+// at /files/images/.
+func (d *Disk) PublicHandler() http.Handler {
+	files := http.FileServer(http.Dir(filepath.Join(d.dir, ImagesDir)))
+
+	return http.StripPrefix("/files/"+ImagesDir, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Directories are not listed.
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		files.ServeHTTP(w, r)
+	}))
+}
@@ -0,0 +1,167 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Channel is the Postgres channel the database publishes events on.
+const Channel = "galleria_events"
+
+const (
+	// subscriberBuffer is how many messages a subscriber can fall behind
+	// before it is dropped.
+	subscriberBuffer = 16
+	reconnectDelay   = 3 * time.Second
+)
+
+// Message tells that the row ID changed in Topic, subscribers load the row
+// themselves.
+type Message struct {
+	Topic string    `json:"topic"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func PostTopic(postID uuid.UUID) string {
+	return "post:" + postID.String()
+}
+
+func UserTopic(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// Broker fans out the events published by the database to the subscribers of
+// this process. Messages are never queued on behalf of a subscriber: when one
+// falls behind, or the broker loses its connection, subscriptions are closed
+// and clients are expected to reconnect and replay what they missed.
+type Broker struct {
+	pool *pgxpool.Pool
+
+	mu          sync.Mutex
+	subscribers map[string]map[chan Message]struct{}
+}
+
+func NewBroker(pool *pgxpool.Pool) *Broker {
+	return &Broker{
+		pool:        pool,
+		subscribers: make(map[string]map[chan Message]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving the messages of topic, it is closed
+// when unsubscribe is called or the broker drops the subscription.
+func (b *Broker) Subscribe(topic string) (messages <-chan Message, unsubscribe func()) {
+	ch := make(chan Message, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[chan Message]struct{})
+	}
+	b.subscribers[topic][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(topic, ch)
+	}
+}
+
+// remove must be called with mu held.
+func (b *Broker) remove(topic string, ch chan Message) {
+	subscribers, ok := b.subscribers[topic]
+	if !ok {
+		return
+	}
+
+	if _, ok := subscribers[ch]; !ok {
+		return
+	}
+
+	delete(subscribers, ch)
+	close(ch)
+	if len(subscribers) == 0 {
+		delete(b.subscribers, topic)
+	}
+}
+
+func (b *Broker) publish(msg Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[msg.Topic] {
+		select {
+		case ch <- msg:
+		default:
+			b.remove(msg.Topic, ch)
+		}
+	}
+}
+
+func (b *Broker) dropAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for topic, subscribers := range b.subscribers {
+		for ch := range subscribers {
+			b.remove(topic, ch)
+		}
+	}
+}
+
+// Run listens for events until ctx is done, reconnecting whenever the
+// connection is lost.
+func (b *Broker) Run(ctx context.Context) {
+	for {
+		err := b.listen(ctx)
+		b.dropAll()
+
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("stream broker disconnected: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (b *Broker) listen(ctx context.Context) error {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The connection keeps listening for as long as it lives, so it is taken
+	// out of the pool for good.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var msg Message
+		if err := json.Unmarshal([]byte(notification.Payload), &msg); err != nil {
+			log.Printf("invalid stream event %q: %v", notification.Payload, err)
+			continue
+		}
+
+		b.publish(msg)
+	}
+}
@@ -0,0 +1,90 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/edulustosa/galleria/helpers"
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+)
+
+const (
+	MaxLength   = 50
+	MaxPerImage = 10
+)
+
+type Tags struct {
+	tagsRepository repo.TagsRepository
+}
+
+func New(tagsRepository repo.TagsRepository) *Tags {
+	return &Tags{tagsRepository}
+}
+
+// Normalize lowercases the tag and drops a leading '#', it returns false if
+// what is left is empty, too long or has anything but letters, digits and
+// underscores.
+func Normalize(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if tag == "" || len([]rune(tag)) > MaxLength {
+		return "", false
+	}
+
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return "", false
+		}
+	}
+
+	return tag, true
+}
+
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])#([\p{L}\p{N}_]+)`)
+
+// Hashtags returns the normalized #hashtags found in text.
+func Hashtags(text string) []string {
+	var hashtags []string
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		if tag, ok := Normalize(match[1]); ok {
+			hashtags = append(hashtags, tag)
+		}
+	}
+
+	return hashtags
+}
+
+// Merge normalizes and deduplicates the given tags, keeping the first
+// occurrence order. Invalid tags are dropped.
+func Merge(lists ...[]string) []string {
+	merged := make([]string, 0)
+	for _, list := range lists {
+		for _, tag := range list {
+			tag, ok := Normalize(tag)
+			if ok && !slices.Contains(merged, tag) {
+				merged = append(merged, tag)
+			}
+		}
+	}
+
+	return merged
+}
+
+const popularLimit = 20
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+// Popular returns the most used tags in the period, one of day, week, month or
+// all.
+func (t *Tags) Popular(ctx context.Context, period string) ([]models.TagCount, error) {
+	within, ok := helpers.Periods[period]
+	if !ok {
+		return nil, ErrInvalidPeriod
+	}
+
+	return t.tagsRepository.Popular(ctx, within, popularLimit)
+}
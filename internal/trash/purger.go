@@ -0,0 +1,52 @@
+package trash
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+)
+
+const (
+	// Retention is how long deleted posts and comments can be restored.
+	Retention = 30 * 24 * time.Hour
+	// purgeInterval is how often expired items are purged, an item may
+	// outlive the retention by as much.
+	purgeInterval = time.Hour
+)
+
+// Purger deletes for good what stayed in the trash longer than Retention.
+type Purger struct {
+	trashRepository repo.TrashRepository
+}
+
+func NewPurger(trashRepository repo.TrashRepository) *Purger {
+	return &Purger{trashRepository}
+}
+
+// Purge deletes the expired items and returns how many there were.
+func (p *Purger) Purge(ctx context.Context) (int64, error) {
+	return p.trashRepository.Purge(ctx, time.Now().Add(-Retention))
+}
+
+// Run purges once and then every hour until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.Purge(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("failed to purge trash: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d items from the trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package trash
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+type Trash struct {
+	trashRepository    repo.TrashRepository
+	imagesRepository   repo.ImagesRepository
+	commentsRepository repo.CommentsRepository
+}
+
+func New(
+	trashRepository repo.TrashRepository,
+	imagesRepository repo.ImagesRepository,
+	commentsRepository repo.CommentsRepository,
+) *Trash {
+	return &Trash{
+		trashRepository:    trashRepository,
+		imagesRepository:   imagesRepository,
+		commentsRepository: commentsRepository,
+	}
+}
+
+var (
+	ErrImageNotFound   = errors.New("post not found")
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrNotOwner        = errors.New("only the author can delete this")
+	ErrNotInTrash      = errors.New("nothing with this id in your trash")
+)
+
+// DeleteImage moves the post to the trash of its owner, taking its comments
+// along with it.
+func (t *Trash) DeleteImage(ctx context.Context, userID, imageID uuid.UUID) error {
+	image, err := t.imagesRepository.FindByID(ctx, imageID, userID)
+	if err != nil {
+		return ErrImageNotFound
+	}
+
+	if image.UserID != userID {
+		return ErrNotOwner
+	}
+
+	return t.trashRepository.DeleteImage(ctx, imageID)
+}
+
+// DeleteComment moves the comment to the trash of its author, its replies
+// stay visible and outlive it once it is purged.
+func (t *Trash) DeleteComment(ctx context.Context, userID, commentID uuid.UUID) error {
+	comment, err := t.commentsRepository.FindByID(ctx, commentID)
+	if err != nil {
+		return ErrCommentNotFound
+	}
+
+	if comment.UserID != userID {
+		return ErrNotOwner
+	}
+
+	return t.trashRepository.DeleteComment(ctx, commentID)
+}
+
+func (t *Trash) RestoreImage(ctx context.Context, userID, imageID uuid.UUID) error {
+	restored, err := t.trashRepository.RestoreImage(ctx, userID, imageID)
+	if err != nil {
+		return err
+	}
+
+	if !restored {
+		return ErrNotInTrash
+	}
+
+	return nil
+}
+
+func (t *Trash) RestoreComment(ctx context.Context, userID, commentID uuid.UUID) error {
+	restored, err := t.trashRepository.RestoreComment(ctx, userID, commentID)
+	if err != nil {
+		return err
+	}
+
+	if !restored {
+		return ErrNotInTrash
+	}
+
+	return nil
+}
+
+// List returns what the user has in the trash along with when each item will
+// be purged.
+func (t *Trash) List(ctx context.Context, userID uuid.UUID) (*models.Trash, error) {
+	trash, err := t.trashRepository.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range trash.Posts {
+		trash.Posts[i].PurgeAt = trash.Posts[i].DeletedAt.Time.Add(Retention)
+	}
+
+	for i := range trash.Comments {
+		trash.Comments[i].PurgeAt = trash.Comments[i].DeletedAt.Time.Add(Retention)
+	}
+
+	return trash, nil
+}
@@ -0,0 +1,327 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/albums"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+func TestAlbums(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	albumsRepository := repo.NewPGXAlbumsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := albums.New(albumsRepository, imagesRepository, usersRepository, blocksRepository)
+
+	ctx := context.Background()
+
+	t.Run("users should be able to curate and reorder an album", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		curatorID, err := SignUpNamedUser(usersRepository, "curator")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		artistID, err := SignUpNamedUser(usersRepository, "artist")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		albumID, err := sut.Create(ctx, curatorID, &albums.CreateAlbumRequest{Title: "favorites"})
+		if err != nil {
+			t.Fatalf("failed to create album: %v", err)
+		}
+
+		var imageIDs []uuid.UUID
+		for _, ownerID := range []uuid.UUID{curatorID, artistID} {
+			imageID, err := CreateImage(imagesRepository, ownerID)
+			if err != nil {
+				t.Fatalf("failed to create image: %v", err)
+			}
+
+			if err := sut.AddImage(ctx, curatorID, albumID, imageID); err != nil {
+				t.Fatalf("failed to add image: %v", err)
+			}
+			imageIDs = append(imageIDs, imageID)
+		}
+
+		reversed := []uuid.UUID{imageIDs[1], imageIDs[0]}
+		err = sut.Reorder(ctx, curatorID, albumID, &albums.ReorderRequest{ImageIDs: reversed})
+		if err != nil {
+			t.Fatalf("failed to reorder album: %v", err)
+		}
+
+		album, images, err := sut.Get(ctx, uuid.Nil, albumID)
+		if err != nil {
+			t.Fatalf("failed to get album: %v", err)
+		}
+
+		if album.ImagesCount != 2 || images[0].Image.ID != imageIDs[1] {
+			t.Errorf("unexpected album: %v %v", album, images)
+		}
+
+		PrettyPrint(album, images)
+	})
+
+	t.Run("reordering should keep the images hidden from the owner in place", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		curatorID, err := SignUpNamedUser(usersRepository, "curator")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		artistID, err := SignUpNamedUser(usersRepository, "artist")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		albumID, err := sut.Create(ctx, curatorID, &albums.CreateAlbumRequest{Title: "favorites"})
+		if err != nil {
+			t.Fatalf("failed to create album: %v", err)
+		}
+
+		var imageIDs []uuid.UUID
+		for _, ownerID := range []uuid.UUID{curatorID, artistID, curatorID} {
+			imageID, err := CreateImage(imagesRepository, ownerID)
+			if err != nil {
+				t.Fatalf("failed to create image: %v", err)
+			}
+
+			if err := sut.AddImage(ctx, curatorID, albumID, imageID); err != nil {
+				t.Fatalf("failed to add image: %v", err)
+			}
+			imageIDs = append(imageIDs, imageID)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE images SET visibility = 'private' WHERE id = $1", imageIDs[1])
+		if err != nil {
+			t.Fatalf("failed to hide image: %v", err)
+		}
+
+		reversed := []uuid.UUID{imageIDs[2], imageIDs[0]}
+		err = sut.Reorder(ctx, curatorID, albumID, &albums.ReorderRequest{ImageIDs: reversed})
+		if err != nil {
+			t.Fatalf("failed to reorder album: %v", err)
+		}
+
+		var position int
+		err = pool.QueryRow(
+			ctx,
+			"SELECT position FROM album_images WHERE album_id = $1 AND image_id = $2",
+			albumID,
+			imageIDs[1],
+		).Scan(&position)
+		if err != nil {
+			t.Fatalf("failed to get position: %v", err)
+		}
+
+		if position != 1 {
+			t.Errorf("expected the hidden image to stay at 1, got %d", position)
+		}
+
+		_, images, err := sut.Get(ctx, curatorID, albumID)
+		if err != nil {
+			t.Fatalf("failed to get album: %v", err)
+		}
+
+		if len(images) != 2 || images[0].Image.ID != imageIDs[2] {
+			t.Errorf("unexpected album images: %v", images)
+		}
+	})
+
+	t.Run("albums should only hold the listed images of other users", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		curatorID, err := SignUpNamedUser(usersRepository, "curator")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		artistID, err := SignUpNamedUser(usersRepository, "artist")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		albumID, err := sut.Create(ctx, curatorID, &albums.CreateAlbumRequest{Title: "favorites"})
+		if err != nil {
+			t.Fatalf("failed to create album: %v", err)
+		}
+
+		unlistedID, err := CreateImage(imagesRepository, artistID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE images SET visibility = 'unlisted' WHERE id = $1", unlistedID)
+		if err != nil {
+			t.Fatalf("failed to unlist image: %v", err)
+		}
+
+		if err := sut.AddImage(ctx, curatorID, albumID, unlistedID); err != albums.ErrImageNotFound {
+			t.Errorf("expected ErrImageNotFound, got %v", err)
+		}
+
+		hiddenID, err := CreateImage(imagesRepository, artistID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		if err := sut.AddImage(ctx, curatorID, albumID, hiddenID); err != nil {
+			t.Fatalf("failed to add image: %v", err)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE images SET hidden_at = NOW() WHERE id = $1", hiddenID)
+		if err != nil {
+			t.Fatalf("failed to hide image: %v", err)
+		}
+
+		album, images, err := sut.Get(ctx, curatorID, albumID)
+		if err != nil {
+			t.Fatalf("failed to get album: %v", err)
+		}
+
+		if album.ImagesCount != 0 || album.CoverURL != nil || len(images) != 0 {
+			t.Errorf("expected the hidden image to be left out, got %v %v", album, images)
+		}
+	})
+
+	t.Run("albums should be listed on the profile of their owner", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		curatorID, err := SignUpNamedUser(usersRepository, "curator")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		trollID, err := SignUpNamedUser(usersRepository, "troll")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		albumID, err := sut.Create(ctx, curatorID, &albums.CreateAlbumRequest{Title: "favorites"})
+		if err != nil {
+			t.Fatalf("failed to create album: %v", err)
+		}
+
+		userAlbums, err := sut.ListByUsername(ctx, uuid.Nil, "Curator")
+		if err != nil {
+			t.Fatalf("failed to list albums: %v", err)
+		}
+
+		if len(userAlbums) != 1 || userAlbums[0].Title != "favorites" {
+			t.Errorf("unexpected albums: %v", userAlbums)
+		}
+
+		if err := blocksRepository.Block(ctx, curatorID, trollID); err != nil {
+			t.Fatalf("failed to block user: %v", err)
+		}
+
+		if _, err := sut.ListByUsername(ctx, trollID, "curator"); err != albums.ErrUserNotFound {
+			t.Errorf("expected ErrUserNotFound, got %v", err)
+		}
+
+		if _, _, err := sut.Get(ctx, trollID, albumID); err != albums.ErrAlbumNotFound {
+			t.Errorf("expected ErrAlbumNotFound, got %v", err)
+		}
+	})
+
+	t.Run("album counts and covers should include the private images of the owner", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		curatorID, err := SignUpNamedUser(usersRepository, "curator")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		albumID, err := sut.Create(ctx, curatorID, &albums.CreateAlbumRequest{Title: "favorites"})
+		if err != nil {
+			t.Fatalf("failed to create album: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, curatorID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		if err := sut.AddImage(ctx, curatorID, albumID, imageID); err != nil {
+			t.Fatalf("failed to add image: %v", err)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE images SET visibility = 'private' WHERE id = $1", imageID)
+		if err != nil {
+			t.Fatalf("failed to update image: %v", err)
+		}
+
+		album, _, err := sut.Get(ctx, curatorID, albumID)
+		if err != nil {
+			t.Fatalf("failed to get album: %v", err)
+		}
+
+		if album.ImagesCount != 1 || album.CoverURL == nil {
+			t.Errorf("expected the owner to see the private image, got %v", album)
+		}
+
+		userAlbums, err := sut.ListByUser(ctx, curatorID)
+		if err != nil {
+			t.Fatalf("failed to list albums: %v", err)
+		}
+
+		if len(userAlbums) != 1 || userAlbums[0].ImagesCount != 1 {
+			t.Errorf("expected the owner to see the private image, got %v", userAlbums)
+		}
+
+		album, _, err = sut.Get(ctx, uuid.Nil, albumID)
+		if err != nil {
+			t.Fatalf("failed to get album: %v", err)
+		}
+
+		if album.ImagesCount != 0 || album.CoverURL != nil {
+			t.Errorf("expected visitors not to see the private image, got %v", album)
+		}
+	})
+
+	t.Run("users should not be able to change other users' albums", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		ownerID, err := SignUpNamedUser(usersRepository, "owner")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		strangerID, err := SignUpNamedUser(usersRepository, "stranger")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		albumID, err := sut.Create(ctx, ownerID, &albums.CreateAlbumRequest{Title: "mine"})
+		if err != nil {
+			t.Fatalf("failed to create album: %v", err)
+		}
+
+		if err := sut.Delete(ctx, strangerID, albumID); err != albums.ErrNotAlbumOwner {
+			t.Errorf("expected ErrNotAlbumOwner, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/analytics"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+func TestAnalytics(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	reactionsRepository := repo.NewPGXReactionsRepository(pool)
+	analyticsRepository := repo.NewPGXAnalyticsRepository(pool)
+	recorder := analytics.NewRecorder(analyticsRepository)
+	sut := analytics.New(analyticsRepository)
+
+	ctx := context.Background()
+
+	t.Run("views should be deduplicated per viewer and rolled up per day", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		ownerID, err := SignUpNamedUser(usersRepository, "owner")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		fanID, err := SignUpNamedUser(usersRepository, "fan")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, ownerID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		recorder.Record(analytics.View, "user:"+fanID.String(), imageID)
+		recorder.Record(analytics.View, "user:"+fanID.String(), imageID)
+		recorder.Record(analytics.View, "ip:203.0.113.7", imageID)
+		recorder.Record(analytics.Impression, "ip:203.0.113.7", imageID)
+
+		if err := reactionsRepository.AddToImage(ctx, fanID, imageID, "❤️"); err != nil {
+			t.Fatalf("failed to react: %v", err)
+		}
+
+		if err := recorder.Flush(ctx); err != nil {
+			t.Fatalf("failed to flush: %v", err)
+		}
+
+		posts, err := sut.Report(ctx, ownerID, "week")
+		if err != nil {
+			t.Fatalf("failed to get analytics: %v", err)
+		}
+
+		if len(posts) != 1 || len(posts[0].Days) != 1 {
+			t.Fatalf("unexpected analytics: %v", posts)
+		}
+
+		totals := posts[0].Totals
+		if totals.Views != 2 || totals.Impressions != 1 || totals.Likes != 1 || totals.Comments != 0 {
+			t.Errorf("unexpected totals: %v", totals)
+		}
+
+		PrettyPrint(posts)
+	})
+
+	t.Run("invalid periods should be rejected", func(t *testing.T) {
+		if _, err := sut.Report(ctx, uuid.Nil, "decade"); err != analytics.ErrInvalidPeriod {
+			t.Errorf("expected ErrInvalidPeriod, got %v", err)
+		}
+	})
+}
@@ -67,6 +67,27 @@ func TestAuth_Register(t *testing.T) {
 
 		t.Log("User 2 not created:", err.Error())
 	})
+
+	t.Run("usernames should stay unique when taken concurrently", func(t *testing.T) {
+		if err = TruncateTables(dbpool); err != nil {
+			t.Fatal("Failed to truncate tables", err.Error())
+		}
+
+		if _, err := SignUpNamedUser(usersRepository, "jane"); err != nil {
+			t.Fatal("Failed to create user", err.Error())
+		}
+
+		// Creating the user directly skips the lookup Register does first,
+		// like a concurrent registration would.
+		_, err := usersRepository.Create(context.Background(), &models.User{
+			Username:     "Jane",
+			Email:        "other@email.com",
+			PasswordHash: "hash",
+		})
+		if err != repo.ErrUsernameTaken {
+			t.Errorf("expected ErrUsernameTaken, got %v", err)
+		}
+	})
 }
 
 func TestAuth_Login(t *testing.T) {
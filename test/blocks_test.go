@@ -0,0 +1,210 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/blocks"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/follows"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/notifications"
+	"github.com/edulustosa/galleria/internal/profile"
+	"github.com/edulustosa/galleria/internal/reactions"
+)
+
+func TestBlocks(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	followsRepository := repo.NewPGXFollowsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	galleriaService := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+	followsService := follows.New(followsRepository, usersRepository, imagesRepository, notificationsRepository, blocksRepository)
+	profileService := profile.New(usersRepository, imagesRepository, followsRepository, blocksRepository)
+	reactionsService := reactions.New(
+		imagesRepository,
+		commentsRepository,
+		repo.NewPGXReactionsRepository(pool),
+		notificationsRepository,
+	)
+	notificationsService := notifications.New(notificationsRepository)
+	sut := blocks.New(blocksRepository, usersRepository)
+
+	ctx := context.Background()
+
+	t.Run("blocked users should not comment, follow or see the profile", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		ownerID, err := SignUpNamedUser(usersRepository, "owner")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		trollID, err := SignUpNamedUser(usersRepository, "troll")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, ownerID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		if err := followsService.Follow(ctx, trollID, ownerID); err != nil {
+			t.Fatalf("failed to follow user: %v", err)
+		}
+
+		if err := sut.Block(ctx, ownerID, trollID); err != nil {
+			t.Fatalf("failed to block user: %v", err)
+		}
+
+		if _, err := galleriaService.AddComment(ctx, trollID, imageID, "hey"); err != galleria.ErrBlocked {
+			t.Errorf("expected ErrBlocked, got %v", err)
+		}
+
+		if err := followsService.Follow(ctx, trollID, ownerID); err != follows.ErrBlocked {
+			t.Errorf("expected ErrBlocked, got %v", err)
+		}
+
+		counts, err := followsRepository.Counts(ctx, ownerID)
+		if err != nil {
+			t.Fatalf("failed to count follows: %v", err)
+		}
+
+		if counts.Followers != 0 {
+			t.Errorf("expected blocking to remove the follow, got %v", counts)
+		}
+
+		if _, err := profileService.GetPublicProfile(ctx, trollID, "owner"); err != profile.ErrUserNotFound {
+			t.Errorf("expected ErrUserNotFound, got %v", err)
+		}
+
+		if err := sut.Unblock(ctx, ownerID, trollID); err != nil {
+			t.Fatalf("failed to unblock user: %v", err)
+		}
+
+		if _, err := galleriaService.AddComment(ctx, trollID, imageID, "sorry"); err != nil {
+			t.Errorf("failed to comment after unblock: %v", err)
+		}
+	})
+
+	t.Run("muted users should be filtered from feeds and comments", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		readerID, err := SignUpNamedUser(usersRepository, "reader")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		noisyID, err := SignUpNamedUser(usersRepository, "noisy")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		readerImageID, err := CreateImage(imagesRepository, readerID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		if _, err := CreateImage(imagesRepository, noisyID); err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		if _, err := galleriaService.AddComment(ctx, noisyID, readerImageID, "first!"); err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		if err := sut.Mute(ctx, readerID, noisyID); err != nil {
+			t.Fatalf("failed to mute user: %v", err)
+		}
+
+		posts, _, err := galleriaService.Browse(ctx, galleria.BrowseOptions{ViewerID: readerID})
+		if err != nil {
+			t.Fatalf("failed to browse: %v", err)
+		}
+
+		if len(posts) != 1 || posts[0].Image.ID != readerImageID {
+			t.Errorf("unexpected feed: %v", posts)
+		}
+
+		comments, err := galleriaService.GetComments(ctx, readerID, readerImageID)
+		if err != nil {
+			t.Fatalf("failed to get comments: %v", err)
+		}
+
+		if len(comments) != 0 {
+			t.Errorf("expected muted comment to be left out, got %v", comments)
+		}
+
+		if err := sut.Mute(ctx, readerID, readerID); err != blocks.ErrCannotBlockSelf {
+			t.Errorf("expected ErrCannotBlockSelf, got %v", err)
+		}
+	})
+	t.Run("muted and blocked users should not reach the user through notifications", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		readerID, err := SignUpNamedUser(usersRepository, "reader")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		noisyID, err := SignUpNamedUser(usersRepository, "noisy")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		trollID, err := SignUpNamedUser(usersRepository, "troll")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		readerImageID, err := CreateImage(imagesRepository, readerID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		if err := sut.Mute(ctx, readerID, noisyID); err != nil {
+			t.Fatalf("failed to mute user: %v", err)
+		}
+
+		if err := sut.Block(ctx, readerID, trollID); err != nil {
+			t.Fatalf("failed to block user: %v", err)
+		}
+
+		if err := reactionsService.ReactToImage(ctx, noisyID, readerImageID, reactions.Like); err != nil {
+			t.Fatalf("failed to react to image: %v", err)
+		}
+
+		description := "look @reader"
+		_, err = galleriaService.SendImage(ctx, trollID, &galleria.SendImageRequest{
+			Title:       "mention",
+			Description: &description,
+			URL:         "https://example.com/image.jpg",
+		})
+		if err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		inbox, err := notificationsService.List(ctx, readerID, "")
+		if err != nil {
+			t.Fatalf("failed to list notifications: %v", err)
+		}
+
+		if len(inbox.Notifications) != 0 || inbox.Unread != 0 {
+			t.Errorf("expected no notifications, got %v", inbox.Notifications)
+		}
+	})
+}
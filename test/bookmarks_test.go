@@ -0,0 +1,83 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/bookmarks"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/google/uuid"
+)
+
+func TestBookmarks(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	bookmarksRepository := repo.NewPGXBookmarksRepository(pool)
+	sut := bookmarks.New(bookmarksRepository, imagesRepository)
+
+	ctx := context.Background()
+
+	t.Run("users should be able to save and unsave posts", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		for range 2 {
+			if err := sut.Save(ctx, userID, imageID); err != nil {
+				t.Fatalf("failed to save post: %v", err)
+			}
+		}
+
+		posts, nextCursor, err := sut.List(ctx, userID, "")
+		if err != nil {
+			t.Fatalf("failed to list bookmarks: %v", err)
+		}
+
+		if len(posts) != 1 || posts[0].Image.ID != imageID || nextCursor != "" {
+			t.Errorf("unexpected bookmarks: %v %q", posts, nextCursor)
+		}
+
+		if err := sut.Unsave(ctx, userID, imageID); err != nil {
+			t.Fatalf("failed to unsave post: %v", err)
+		}
+
+		posts, _, err = sut.List(ctx, userID, "")
+		if err != nil {
+			t.Fatalf("failed to list bookmarks: %v", err)
+		}
+
+		if len(posts) != 0 {
+			t.Errorf("expected no bookmarks, got %v", posts)
+		}
+	})
+
+	t.Run("saving a missing post should fail", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		if err := sut.Save(ctx, userID, uuid.New()); err != bookmarks.ErrImageNotFound {
+			t.Errorf("expected ErrImageNotFound, got %v", err)
+		}
+	})
+}
@@ -17,7 +17,9 @@ func TestComment(t *testing.T) {
 	usersRepository := repo.NewPGXUsersRepository(pool)
 	imagesRepository := repo.NewPGXImagesRepository(pool)
 	commentsRepository := repo.NewPGXCommentsRepository(pool)
-	sut := galleria.New(usersRepository, imagesRepository, commentsRepository)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
 
 	ctx := context.Background()
 
@@ -0,0 +1,180 @@
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/exports"
+	"github.com/edulustosa/galleria/internal/storage"
+)
+
+func readIndex(t *testing.T, data []byte) (exports.Index, map[string]bool) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+
+	names := make(map[string]bool)
+	var index exports.Index
+	for _, f := range archive.File {
+		names[f.Name] = true
+		if f.Name != "index.json" {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open index: %v", err)
+		}
+
+		if err := json.NewDecoder(r).Decode(&index); err != nil {
+			t.Fatalf("failed to decode index: %v", err)
+		}
+		r.Close()
+	}
+
+	return index, names
+}
+
+func TestExports(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	files := storage.New(t.TempDir(), "http://localhost:8080")
+	sut := exports.New(repo.NewPGXExportsRepository(pool), imagesRepository, files)
+	worker := exports.NewWorker(sut)
+
+	ctx := context.Background()
+
+	t.Run("archives should hold the images, their metadata and an index", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		if err := files.Put("images/stored.png", strings.NewReader("png")); err != nil {
+			t.Fatalf("failed to store image: %v", err)
+		}
+
+		storedID, err := imagesRepository.Create(ctx, &models.Image{
+			UserID: userID,
+			Title:  "stored",
+			URL:    files.URL("images/stored.png"),
+		})
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		// Internal addresses are refused, the image is noted as missing.
+		if _, err := imagesRepository.Create(ctx, &models.Image{
+			UserID: userID,
+			Title:  "unreachable",
+			URL:    "http://127.0.0.1/image.png",
+		}); err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		images, err := sut.Images(ctx, userID)
+		if err != nil {
+			t.Fatalf("failed to get images: %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := sut.Write(ctx, &buf, images, nil); err != nil {
+			t.Fatalf("failed to write archive: %v", err)
+		}
+
+		index, names := readIndex(t, buf.Bytes())
+		if len(index.Images) != 2 {
+			t.Fatalf("unexpected index: %v", index)
+		}
+
+		for _, entry := range index.Images {
+			if !names[entry.Metadata] {
+				t.Errorf("expected metadata of %s in the archive", entry.ID)
+			}
+
+			stored := entry.ID == storedID.String()
+			if stored && (entry.File != "images/"+storedID.String()+".png" || !names[entry.File]) {
+				t.Errorf("expected the stored image in the archive, got %v", entry)
+			}
+
+			if !stored && (entry.File != "" || entry.Error == "") {
+				t.Errorf("expected the unreachable image to be noted, got %v", entry)
+			}
+		}
+	})
+
+	t.Run("background exports should be downloadable once completed", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		if _, err := CreateImage(imagesRepository, userID); err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		job, err := sut.Start(ctx, userID, 1)
+		if err != nil {
+			t.Fatalf("failed to start export: %v", err)
+		}
+
+		again, err := sut.Start(ctx, userID, 1)
+		if err != nil || again.ID != job.ID {
+			t.Fatalf("expected the pending export to be reused, got %v %v", again, err)
+		}
+
+		if _, err := sut.Open(ctx, userID, job.ID); err != exports.ErrExportNotReady {
+			t.Errorf("expected ErrExportNotReady, got %v", err)
+		}
+
+		processed, err := worker.ProcessNext(ctx)
+		if err != nil || !processed {
+			t.Fatalf("expected the job to be processed, got %v %v", processed, err)
+		}
+
+		job, err = sut.Get(ctx, userID, job.ID)
+		if err != nil {
+			t.Fatalf("failed to get export: %v", err)
+		}
+
+		if job.Status != "completed" || job.Processed != 1 || job.DownloadURL == nil {
+			t.Fatalf("unexpected job: %v", job)
+		}
+
+		f, err := sut.Open(ctx, userID, job.ID)
+		if err != nil {
+			t.Fatalf("failed to open export: %v", err)
+		}
+		defer f.Close()
+
+		data, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatalf("failed to read export: %v", err)
+		}
+
+		if index, _ := readIndex(t, data); len(index.Images) != 1 {
+			t.Errorf("unexpected index: %v", index)
+		}
+	})
+}
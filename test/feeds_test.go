@@ -0,0 +1,89 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/api/handlers"
+	"github.com/edulustosa/galleria/internal/database/repo"
+)
+
+func TestFeeds(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+
+	t.Run("feeds should be revalidated with conditional requests", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		sut := handlers.HandleGalleriaAtom(pool, "https://galleria.example")
+
+		rec := httptest.NewRecorder()
+		sut(rec, httptest.NewRequest(http.MethodGet, "/galleria.atom", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		body := rec.Body.String()
+		if !strings.Contains(body, "urn:uuid:"+imageID.String()) ||
+			!strings.Contains(body, `rel="enclosure"`) {
+			t.Errorf("expected the post in the feed, got %s", body)
+		}
+
+		etag := rec.Header().Get("ETag")
+		lastModified := rec.Header().Get("Last-Modified")
+		if etag == "" || lastModified == "" {
+			t.Fatalf("expected validators, got %v", rec.Header())
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/galleria.atom", nil)
+		req.Header.Set("If-None-Match", etag)
+		rec = httptest.NewRecorder()
+		sut(rec, req)
+
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("expected 304 for a matching etag, got %d", rec.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/galleria.atom", nil)
+		req.Header.Set("If-Modified-Since", lastModified)
+		rec = httptest.NewRecorder()
+		sut(rec, req)
+
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("expected 304 when not modified since, got %d", rec.Code)
+		}
+
+		if _, err := CreateImage(imagesRepository, userID); err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/galleria.atom", nil)
+		req.Header.Set("If-None-Match", etag)
+		rec = httptest.NewRecorder()
+		sut(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected the changed feed to be sent, got %d", rec.Code)
+		}
+	})
+}
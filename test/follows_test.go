@@ -0,0 +1,127 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/follows"
+	"github.com/google/uuid"
+)
+
+func TestFollows(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	followsRepository := repo.NewPGXFollowsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := follows.New(followsRepository, usersRepository, imagesRepository, notificationsRepository, blocksRepository)
+
+	ctx := context.Background()
+
+	t.Run("home feed should only show posts of followed accounts", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		readerID, err := SignUpNamedUser(usersRepository, "reader")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		followedID, err := SignUpNamedUser(usersRepository, "followed")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		strangerID, err := SignUpNamedUser(usersRepository, "stranger")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		followedImageID, err := CreateImage(imagesRepository, followedID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		if _, err := CreateImage(imagesRepository, strangerID); err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		if err := sut.Follow(ctx, readerID, followedID); err != nil {
+			t.Fatalf("failed to follow user: %v", err)
+		}
+
+		posts, nextCursor, err := sut.Feed(ctx, readerID, "")
+		if err != nil {
+			t.Fatalf("failed to get feed: %v", err)
+		}
+
+		if len(posts) != 1 || posts[0].Image.ID != followedImageID || nextCursor != "" {
+			t.Errorf("unexpected feed: %v %q", posts, nextCursor)
+		}
+
+		followers, err := sut.Followers(ctx, followedID, "")
+		if err != nil {
+			t.Fatalf("failed to get followers: %v", err)
+		}
+
+		if len(followers.Users) != 1 || followers.Users[0].ID != readerID ||
+			followers.Counts.Followers != 1 {
+			t.Errorf("unexpected followers: %v", followers)
+		}
+
+		if err := sut.Unfollow(ctx, readerID, followedID); err != nil {
+			t.Fatalf("failed to unfollow user: %v", err)
+		}
+
+		posts, _, err = sut.Feed(ctx, readerID, "")
+		if err != nil {
+			t.Fatalf("failed to get feed: %v", err)
+		}
+
+		if len(posts) != 0 {
+			t.Errorf("expected empty feed, got %v", posts)
+		}
+	})
+
+	t.Run("users should not be able to follow themselves", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		if err := sut.Follow(ctx, userID, userID); err != follows.ErrCannotFollowSelf {
+			t.Errorf("expected ErrCannotFollowSelf, got %v", err)
+		}
+	})
+	t.Run("cursors of one follow list should not be accepted by the other", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		cursor := repo.Cursor{Kind: repo.CursorFollowers, CreatedAt: time.Now(), ID: uuid.New()}
+		if _, err := sut.Following(ctx, userID, cursor.String()); err != follows.ErrInvalidCursor {
+			t.Errorf("expected ErrInvalidCursor, got %v", err)
+		}
+
+		if _, err := sut.Followers(ctx, userID, cursor.String()); err != nil {
+			t.Errorf("expected the cursor to be accepted, got %v", err)
+		}
+	})
+}
@@ -2,8 +2,10 @@ package test
 
 import (
 	"context"
+	"slices"
 	"testing"
 
+	"github.com/edulustosa/galleria/internal/database/models"
 	"github.com/edulustosa/galleria/internal/database/repo"
 	"github.com/edulustosa/galleria/internal/galleria"
 	"github.com/google/uuid"
@@ -18,7 +20,9 @@ func TestGalleria_SendImage(t *testing.T) {
 	usersRepository := repo.NewPGXUsersRepository(pool)
 	imagesRepository := repo.NewPGXImagesRepository(pool)
 	commentsRepository := repo.NewPGXCommentsRepository(pool)
-	sut := galleria.New(usersRepository, imagesRepository, commentsRepository)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
 
 	testCtx := context.Background()
 
@@ -87,7 +91,9 @@ func TestGalleria_Display(t *testing.T) {
 	usersRepository := repo.NewPGXUsersRepository(pool)
 	imagesRepository := repo.NewPGXImagesRepository(pool)
 	commentsRepository := repo.NewPGXCommentsRepository(pool)
-	sut := galleria.New(usersRepository, imagesRepository, commentsRepository)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
 
 	ctx := context.Background()
 
@@ -156,4 +162,164 @@ func TestGalleria_Display(t *testing.T) {
 
 		PrettyPrint(posts)
 	})
+	t.Run("users should be able to follow the feed cursor", func(t *testing.T) {
+		if err = TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userId, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		for i := 0; i < 22; i++ {
+			req := &galleria.SendImageRequest{
+				Title: "image title",
+				URL:   "http://image.com",
+			}
+
+			_, err = sut.SendImage(ctx, userId, req)
+			if err != nil {
+				t.Fatalf("failed to send image: %v", err)
+			}
+		}
+
+		firstPage, cursor, err := sut.Browse(ctx, galleria.BrowseOptions{})
+		if err != nil {
+			t.Fatalf("failed to display images: %v", err)
+		}
+
+		if cursor == "" {
+			t.Fatalf("expected a cursor after a full page")
+		}
+
+		secondPage, next, err := sut.Browse(ctx, galleria.BrowseOptions{Cursor: cursor})
+		if err != nil {
+			t.Fatalf("failed to display images: %v", err)
+		}
+
+		if len(secondPage) != 2 {
+			t.Errorf("unexpected number of posts: %v", len(secondPage))
+		}
+
+		seen := make(map[uuid.UUID]bool)
+		for _, post := range append(firstPage, secondPage...) {
+			if seen[post.Image.ID] {
+				t.Errorf("post %v returned twice", post.Image.ID)
+			}
+			seen[post.Image.ID] = true
+		}
+
+		if next != "" {
+			t.Errorf("expected no cursor after the last page")
+		}
+	})
+
+	t.Run("an invalid cursor should be rejected", func(t *testing.T) {
+		_, _, err := sut.Browse(ctx, galleria.BrowseOptions{Cursor: "not a cursor"})
+		if err != galleria.ErrInvalidCursor {
+			t.Errorf("expected ErrInvalidCursor, got %v", err)
+		}
+	})
+
+	t.Run("users should be able to sort the galleria by top posts", func(t *testing.T) {
+		if err = TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userId, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		var imageIds []uuid.UUID
+		for i := 0; i < 4; i++ {
+			imageId, err := CreateImage(imagesRepository, userId)
+			if err != nil {
+				t.Fatalf("failed to create image: %v", err)
+			}
+			imageIds = append(imageIds, imageId)
+		}
+
+		_, err = sut.AddComment(ctx, userId, imageIds[0], "first!")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		reactionsRepository := repo.NewPGXReactionsRepository(pool)
+		for _, imageId := range []uuid.UUID{imageIds[1], imageIds[3]} {
+			for _, emoji := range []string{"🔥", "👏"} {
+				err := reactionsRepository.AddToImage(ctx, userId, imageId, emoji)
+				if err != nil {
+					t.Fatalf("failed to react: %v", err)
+				}
+			}
+		}
+
+		// The posts are backdated so that the engagement of the older ones does
+		// not make up for their age, and the last one falls out of the day.
+		for i, age := range []string{"1 hour", "2 hours", "0 hours", "3 days"} {
+			_, err := pool.Exec(
+				ctx,
+				"UPDATE images SET created_at = LOCALTIMESTAMP - $2::interval WHERE id = $1",
+				imageIds[i],
+				age,
+			)
+			if err != nil {
+				t.Fatalf("failed to backdate image: %v", err)
+			}
+
+			_, err = pool.Exec(ctx, `
+				UPDATE image_stats
+				SET created_at = LOCALTIMESTAMP - $2::interval,
+					hot_score = image_hot_score(
+						reactions_count + 2 * comments_count,
+						LOCALTIMESTAMP - $2::interval
+					)
+				WHERE image_id = $1`, imageIds[i], age)
+			if err != nil {
+				t.Fatalf("failed to backdate image stats: %v", err)
+			}
+		}
+
+		ids := func(posts []models.Post) []uuid.UUID {
+			ids := make([]uuid.UUID, len(posts))
+			for i, post := range posts {
+				ids[i] = post.Image.ID
+			}
+			return ids
+		}
+
+		top, _, err := sut.Browse(ctx, galleria.BrowseOptions{Sort: "top", Period: "day"})
+		if err != nil {
+			t.Fatalf("failed to display images: %v", err)
+		}
+
+		expected := []uuid.UUID{imageIds[1], imageIds[2], imageIds[0]}
+		if !slices.Equal(ids(top), expected) {
+			t.Errorf("expected the most reacted posts of the day first, got %v", ids(top))
+		}
+
+		allTime, _, err := sut.Browse(ctx, galleria.BrowseOptions{Sort: "top"})
+		if err != nil {
+			t.Fatalf("failed to display images: %v", err)
+		}
+
+		expected = []uuid.UUID{imageIds[1], imageIds[3], imageIds[2], imageIds[0]}
+		if !slices.Equal(ids(allTime), expected) {
+			t.Errorf("expected older posts to rank of all time, got %v", ids(allTime))
+		}
+
+		trending, _, err := sut.Browse(ctx, galleria.BrowseOptions{Sort: "trending"})
+		if err != nil {
+			t.Fatalf("failed to display images: %v", err)
+		}
+
+		expected = []uuid.UUID{imageIds[0], imageIds[1], imageIds[2], imageIds[3]}
+		if !slices.Equal(ids(trending), expected) {
+			t.Errorf("expected engaged and recent posts to trend, got %v", ids(trending))
+		}
+
+		PrettyPrint(top, trending)
+	})
 }
@@ -0,0 +1,138 @@
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/imports"
+	"github.com/edulustosa/galleria/internal/storage"
+	"github.com/google/uuid"
+)
+
+func importArchive(t *testing.T, manifest string) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	w, err := archive.Create("manifest.csv")
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	w.Write([]byte(manifest))
+
+	w, err = archive.Create("photos/sunset.png")
+	if err != nil {
+		t.Fatalf("failed to create image: %v", err)
+	}
+	png.Encode(w, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+
+	if err := archive.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestImports(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	galleriaService := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+	files := storage.New(t.TempDir(), "http://localhost:8080")
+	sut := imports.New(repo.NewPGXImportsRepository(pool), galleriaService, files)
+	worker := imports.NewWorker(sut)
+
+	ctx := context.Background()
+
+	t.Run("archives should be imported with a report per row", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		data := importArchive(t, "title,file,url,tags\n"+
+			"sunset,photos/sunset.png,,sky sunset\n"+
+			"linked,,https://example.com/image.jpg,\n"+
+			",photos/missing.png,,\n")
+
+		job, err := sut.Start(ctx, userID, "zip", data)
+		if err != nil {
+			t.Fatalf("failed to start import: %v", err)
+		}
+
+		if job.Status != "pending" || job.Total != 3 {
+			t.Fatalf("unexpected job: %v", job)
+		}
+
+		processed, err := worker.ProcessNext(ctx)
+		if err != nil || !processed {
+			t.Fatalf("expected the job to be processed, got %v %v", processed, err)
+		}
+
+		job, err = sut.Get(ctx, userID, job.ID)
+		if err != nil {
+			t.Fatalf("failed to get import: %v", err)
+		}
+
+		if job.Status != "completed" || job.Succeeded != 2 || job.Failed != 1 || len(job.Report) != 3 {
+			t.Fatalf("unexpected job: %v", job)
+		}
+
+		failed := job.Report[2]
+		if failed.Row != 3 || failed.Problems["title"] == "" || failed.Problems["file"] == "" {
+			t.Errorf("unexpected result: %v", failed)
+		}
+
+		imported, err := galleriaService.GetImage(ctx, uuid.Nil, *job.Report[0].ImageID)
+		if err != nil {
+			t.Fatalf("failed to get imported image: %v", err)
+		}
+
+		if _, err := files.Open(imported.URL[len("http://localhost:8080/files/"):]); err != nil {
+			t.Errorf("expected the image to be stored, got %v", err)
+		}
+
+		if _, err := sut.Get(ctx, uuid.New(), job.ID); err != imports.ErrImportNotFound {
+			t.Errorf("expected ErrImportNotFound, got %v", err)
+		}
+
+		PrettyPrint(job)
+	})
+
+	t.Run("unreadable manifests should be rejected", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		_, err = sut.Start(ctx, userID, "csv", []byte("title,unknown\nx,y\n"))
+		if err == nil {
+			t.Errorf("expected an unknown column to be rejected")
+		}
+
+		_, err = sut.Start(ctx, userID, "json", []byte(`{"title": "not a list"}`))
+		if err == nil {
+			t.Errorf("expected a json object to be rejected")
+		}
+	})
+}
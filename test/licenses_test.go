@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/licenses"
+	"github.com/google/uuid"
+)
+
+func TestLicenses(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+
+	ctx := context.Background()
+
+	t.Run("posts should default to the license of the user and be filtered by it", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		user, err := usersRepository.FindByID(ctx, userID)
+		if err != nil {
+			t.Fatalf("failed to find user: %v", err)
+		}
+
+		user.DefaultLicense = "CC-BY-4.0"
+		if err := usersRepository.Update(ctx, user); err != nil {
+			t.Fatalf("failed to update user: %v", err)
+		}
+
+		defaultID, err := sut.SendImage(ctx, userID, &galleria.SendImageRequest{
+			Title: "by default",
+			URL:   "https://example.com/image.jpg",
+		})
+		if err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		source := "https://example.com/original"
+		if _, err := sut.SendImage(ctx, userID, &galleria.SendImageRequest{
+			Title:     "reserved",
+			URL:       "https://example.com/image.jpg",
+			License:   licenses.Default,
+			SourceURL: &source,
+		}); err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		image, err := sut.GetImage(ctx, uuid.Nil, defaultID)
+		if err != nil {
+			t.Fatalf("failed to get image: %v", err)
+		}
+
+		if image.License.ID != "CC-BY-4.0" || !image.License.Attribution || image.License.URL == "" {
+			t.Errorf("unexpected license: %v", image.License)
+		}
+
+		posts, _, err := sut.Browse(ctx, galleria.BrowseOptions{Licenses: []string{"CC-BY-4.0", "CC0-1.0"}})
+		if err != nil {
+			t.Fatalf("failed to browse: %v", err)
+		}
+
+		if len(posts) != 1 || posts[0].Image.ID != defaultID {
+			t.Errorf("unexpected feed: %v", posts)
+		}
+	})
+
+	t.Run("unknown licenses should be rejected", func(t *testing.T) {
+		req := galleria.SendImageRequest{
+			Title:   "image",
+			URL:     "https://example.com/image.jpg",
+			License: "MIT",
+		}
+
+		if problems := req.Valid(); problems["license"] == "" {
+			t.Errorf("expected a license problem, got %v", problems)
+		}
+	})
+}
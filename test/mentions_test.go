@@ -0,0 +1,194 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/mentions"
+	"github.com/edulustosa/galleria/internal/notifications"
+	"github.com/google/uuid"
+)
+
+func TestMentions_Find(t *testing.T) {
+	found := mentions.Find("olá @jane, see @joe_doe. email@example.com @")
+
+	if len(found) != 2 {
+		t.Fatalf("expected 2 mentions, got %v", found)
+	}
+
+	if found[0].Username != "jane" || found[0].Start != 4 || found[0].End != 9 {
+		t.Errorf("unexpected first mention: %v", found[0])
+	}
+
+	if found[1].Username != "joe_doe" || found[1].Start != 15 || found[1].End != 23 {
+		t.Errorf("unexpected second mention: %v", found[1])
+	}
+}
+
+func TestMentions_ValidUsername(t *testing.T) {
+	for _, username := range []string{"jane", "joe_doe", "j.doe", "joão"} {
+		if !mentions.ValidUsername(username) {
+			t.Errorf("expected %q to be valid", username)
+		}
+	}
+
+	for _, username := range []string{"john doe", "a/b", "jane-doe", "jane.", ".jane"} {
+		if mentions.ValidUsername(username) {
+			t.Errorf("expected %q to be invalid", username)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+	notificationsService := notifications.New(notificationsRepository)
+
+	ctx := context.Background()
+
+	t.Run("mentioned users should be linked and notified", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		authorID, err := SignUpNamedUser(usersRepository, "author")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		janeID, err := SignUpNamedUser(usersRepository, "Jane")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, authorID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		commentID, err := sut.AddComment(ctx, authorID, imageID, "look @jane and @nobody")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		comment, err := sut.GetComment(ctx, uuid.Nil, commentID)
+		if err != nil {
+			t.Fatalf("failed to get comment: %v", err)
+		}
+
+		if len(comment.Mentions) != 1 || comment.Mentions[0].UserID != janeID ||
+			comment.Mentions[0].Username != "Jane" {
+			t.Errorf("unexpected mentions: %v", comment.Mentions)
+		}
+
+		inbox, err := notificationsService.List(ctx, janeID, "")
+		if err != nil {
+			t.Fatalf("failed to list notifications: %v", err)
+		}
+
+		if len(inbox.Notifications) != 1 || inbox.Notifications[0].Type != "mention" {
+			t.Errorf("expected a mention notification, got %v", inbox.Notifications)
+		}
+
+		PrettyPrint(comment)
+	})
+	t.Run("users mentioned in comments on private posts should not be notified", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		authorID, err := SignUpNamedUser(usersRepository, "author")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		janeID, err := SignUpNamedUser(usersRepository, "jane")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, authorID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE images SET visibility = 'private' WHERE id = $1", imageID)
+		if err != nil {
+			t.Fatalf("failed to make image private: %v", err)
+		}
+
+		if _, err := sut.AddComment(ctx, authorID, imageID, "look @jane"); err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		inbox, err := notificationsService.List(ctx, janeID, "")
+		if err != nil {
+			t.Fatalf("failed to list notifications: %v", err)
+		}
+
+		if len(inbox.Notifications) != 0 {
+			t.Errorf("expected no notifications, got %v", inbox.Notifications)
+		}
+	})
+	t.Run("users mentioned on a private post should be notified once it is shared", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		authorID, err := SignUpNamedUser(usersRepository, "author")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		janeID, err := SignUpNamedUser(usersRepository, "jane")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		description := "with @jane"
+		imageID, err := sut.SendImage(ctx, authorID, &galleria.SendImageRequest{
+			Title:       "private",
+			Description: &description,
+			URL:         "https://example.com/image.jpg",
+			Visibility:  "private",
+		})
+		if err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		inbox, err := notificationsService.List(ctx, janeID, "")
+		if err != nil {
+			t.Fatalf("failed to list notifications: %v", err)
+		}
+
+		if len(inbox.Notifications) != 0 {
+			t.Fatalf("expected no notifications, got %v", inbox.Notifications)
+		}
+
+		visibility := "unlisted"
+		err = sut.UpdateImage(ctx, authorID, imageID, &galleria.UpdateImageRequest{Visibility: &visibility})
+		if err != nil {
+			t.Fatalf("failed to update image: %v", err)
+		}
+
+		inbox, err = notificationsService.List(ctx, janeID, "")
+		if err != nil {
+			t.Fatalf("failed to list notifications: %v", err)
+		}
+
+		if len(inbox.Notifications) != 1 || inbox.Notifications[0].Type != "mention" {
+			t.Errorf("expected a mention notification, got %v", inbox.Notifications)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/moderation"
+	"github.com/google/uuid"
+)
+
+func TestModeration(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	reportsRepository := repo.NewPGXReportsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	galleriaService := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+	sut := moderation.New(reportsRepository, usersRepository, imagesRepository, commentsRepository)
+
+	ctx := context.Background()
+
+	t.Run("hidden comments should be left out of the comment list", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		authorID, err := SignUpNamedUser(usersRepository, "author")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		moderatorID, err := SignUpNamedUser(usersRepository, "moderator")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE users SET is_moderator = true WHERE id = $1", moderatorID)
+		if err != nil {
+			t.Fatalf("failed to appoint moderator: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, authorID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		commentID, err := galleriaService.AddComment(ctx, authorID, imageID, "buy cheap stuff")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		report := &moderation.ReportRequest{Reason: "spam"}
+		if err := sut.Report(ctx, moderatorID, repo.ReportComment, commentID, report); err != nil {
+			t.Fatalf("failed to report comment: %v", err)
+		}
+
+		err = sut.Report(ctx, moderatorID, repo.ReportComment, commentID, report)
+		if err != moderation.ErrAlreadyReported {
+			t.Errorf("expected ErrAlreadyReported, got %v", err)
+		}
+
+		if _, _, err := sut.Queue(ctx, authorID, "", ""); err != moderation.ErrNotModerator {
+			t.Errorf("expected ErrNotModerator, got %v", err)
+		}
+
+		reports, _, err := sut.Queue(ctx, moderatorID, "", "")
+		if err != nil {
+			t.Fatalf("failed to get moderation queue: %v", err)
+		}
+
+		if len(reports) != 1 || reports[0].TargetID != commentID {
+			t.Fatalf("unexpected reports: %v", reports)
+		}
+
+		resolve := &moderation.ResolveRequest{Action: repo.ActionHide}
+		if err := sut.Resolve(ctx, moderatorID, reports[0].ID, resolve); err != nil {
+			t.Fatalf("failed to resolve report: %v", err)
+		}
+
+		comments, err := galleriaService.GetComments(ctx, uuid.Nil, imageID)
+		if err != nil {
+			t.Fatalf("failed to get comments: %v", err)
+		}
+
+		if len(comments) != 0 {
+			t.Errorf("expected hidden comment to be left out, got %v", comments)
+		}
+
+		reports, _, err = sut.Queue(ctx, moderatorID, "", "")
+		if err != nil {
+			t.Fatalf("failed to get moderation queue: %v", err)
+		}
+
+		if len(reports) != 0 {
+			t.Errorf("expected empty queue, got %v", reports)
+		}
+	})
+
+	t.Run("users should not be hidden or removed", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpNamedUser(usersRepository, "reported")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		moderatorID, err := SignUpNamedUser(usersRepository, "moderator")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE users SET is_moderator = true WHERE id = $1", moderatorID)
+		if err != nil {
+			t.Fatalf("failed to appoint moderator: %v", err)
+		}
+
+		report := &moderation.ReportRequest{Reason: "harassment"}
+		if err := sut.Report(ctx, moderatorID, repo.ReportUser, userID, report); err != nil {
+			t.Fatalf("failed to report user: %v", err)
+		}
+
+		reports, _, err := sut.Queue(ctx, moderatorID, "open", "")
+		if err != nil || len(reports) != 1 {
+			t.Fatalf("unexpected queue: %v %v", reports, err)
+		}
+
+		resolve := &moderation.ResolveRequest{Action: repo.ActionRemove}
+		if err := sut.Resolve(ctx, moderatorID, reports[0].ID, resolve); err != moderation.ErrInvalidAction {
+			t.Errorf("expected ErrInvalidAction, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/notifications"
+	"github.com/edulustosa/galleria/internal/reactions"
+	"github.com/google/uuid"
+)
+
+func TestNotifications(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	reactionsRepository := repo.NewPGXReactionsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	galleriaService := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+	reactionsService := reactions.New(imagesRepository, commentsRepository, reactionsRepository, notificationsRepository)
+	sut := notifications.New(notificationsRepository)
+
+	ctx := context.Background()
+
+	t.Run("repeated likes should be grouped into one notification", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		ownerID, err := SignUpNamedUser(usersRepository, "owner")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, ownerID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		for _, username := range []string{"fan1", "fan2", "fan3"} {
+			fanID, err := SignUpNamedUser(usersRepository, username)
+			if err != nil {
+				t.Fatalf("failed to sign up user: %v", err)
+			}
+
+			if err := reactionsService.ReactToImage(ctx, fanID, imageID, reactions.Like); err != nil {
+				t.Fatalf("failed to like image: %v", err)
+			}
+		}
+
+		// Liking your own image is not worth a notification.
+		if err := reactionsService.ReactToImage(ctx, ownerID, imageID, reactions.Like); err != nil {
+			t.Fatalf("failed to like image: %v", err)
+		}
+
+		inbox, err := sut.List(ctx, ownerID, "")
+		if err != nil {
+			t.Fatalf("failed to list notifications: %v", err)
+		}
+
+		if len(inbox.Notifications) != 1 || inbox.Unread != 1 {
+			t.Fatalf("expected one grouped notification, got %v", inbox)
+		}
+
+		like := inbox.Notifications[0]
+		if like.Type != "like" || like.ActorsCount != 3 || like.Actors[0].Username != "fan3" {
+			t.Errorf("unexpected notification: %v", like)
+		}
+
+		if err := sut.MarkRead(ctx, ownerID, like.ID); err != nil {
+			t.Fatalf("failed to mark notification as read: %v", err)
+		}
+
+		if err := sut.MarkRead(ctx, ownerID, uuid.New()); err != notifications.ErrNotificationNotFound {
+			t.Errorf("expected ErrNotificationNotFound, got %v", err)
+		}
+
+		PrettyPrint(like)
+	})
+
+	t.Run("replies should notify the comment author and the post owner", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		ownerID, err := SignUpNamedUser(usersRepository, "owner")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		commenterID, err := SignUpNamedUser(usersRepository, "commenter")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		replierID, err := SignUpNamedUser(usersRepository, "replier")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, ownerID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		commentID, err := galleriaService.AddComment(ctx, commenterID, imageID, "nice")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		if _, err := galleriaService.Reply(ctx, replierID, imageID, commentID, "agreed"); err != nil {
+			t.Fatalf("failed to reply: %v", err)
+		}
+
+		inbox, err := sut.List(ctx, commenterID, "")
+		if err != nil {
+			t.Fatalf("failed to list notifications: %v", err)
+		}
+
+		if len(inbox.Notifications) != 1 || inbox.Notifications[0].Type != "reply" {
+			t.Errorf("expected a reply notification, got %v", inbox.Notifications)
+		}
+
+		inbox, err = sut.List(ctx, ownerID, "")
+		if err != nil {
+			t.Fatalf("failed to list notifications: %v", err)
+		}
+
+		if len(inbox.Notifications) != 1 || inbox.Notifications[0].ActorsCount != 2 {
+			t.Errorf("expected a grouped comment notification, got %v", inbox.Notifications)
+		}
+
+		if err := sut.MarkAllRead(ctx, ownerID); err != nil {
+			t.Fatalf("failed to mark notifications as read: %v", err)
+		}
+
+		inbox, err = sut.List(ctx, ownerID, "")
+		if err != nil {
+			t.Fatalf("failed to list notifications: %v", err)
+		}
+
+		if inbox.Unread != 0 {
+			t.Errorf("expected no unread notifications, got %d", inbox.Unread)
+		}
+	})
+}
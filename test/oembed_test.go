@@ -0,0 +1,127 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/oembed"
+)
+
+func TestOEmbed(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	sut := oembed.New(imagesRepository, usersRepository, "https://galleria.example")
+
+	ctx := context.Background()
+
+	t.Run("posts should resolve to photos fitting the requested size", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpNamedUser(usersRepository, "painter")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		postURL := "https://galleria.example/galleria/posts/" + imageID.String()
+		res, err := sut.Resolve(ctx, oembed.Request{URL: postURL})
+		if err != nil {
+			t.Fatalf("failed to resolve post: %v", err)
+		}
+
+		if res.Type != "link" || res.AuthorName != "painter" {
+			t.Errorf("expected a link until the dimensions are known, got %v", res)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE images SET width = 2000, height = 1000 WHERE id = $1", imageID)
+		if err != nil {
+			t.Fatalf("failed to set dimensions: %v", err)
+		}
+
+		res, err = sut.Resolve(ctx, oembed.Request{URL: postURL, MaxWidth: 800, MaxHeight: 300})
+		if err != nil {
+			t.Fatalf("failed to resolve post: %v", err)
+		}
+
+		if res.Type != "photo" || res.Width != 600 || res.Height != 300 ||
+			res.ThumbnailWidth != 320 || res.ThumbnailHeight != 160 {
+			t.Errorf("unexpected response: %v", res)
+		}
+
+		if _, err := sut.Resolve(ctx, oembed.Request{
+			URL: "https://elsewhere.example/galleria/posts/" + imageID.String(),
+		}); err != oembed.ErrInvalidURL {
+			t.Errorf("expected ErrInvalidURL, got %v", err)
+		}
+
+		PrettyPrint(res)
+	})
+
+	t.Run("private posts should not be embedded", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := imagesRepository.Create(ctx, &models.Image{
+			UserID:     userID,
+			Title:      "private",
+			URL:        "https://example.com/image.jpg",
+			Visibility: "private",
+		})
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		_, err = sut.Resolve(ctx, oembed.Request{
+			URL: "https://galleria.example/galleria/posts/" + imageID.String(),
+		})
+		if err != oembed.ErrPostNotFound {
+			t.Errorf("expected ErrPostNotFound, got %v", err)
+		}
+	})
+	t.Run("posts hidden by moderators should not be embedded", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE images SET hidden_at = NOW() WHERE id = $1", imageID)
+		if err != nil {
+			t.Fatalf("failed to hide image: %v", err)
+		}
+
+		_, err = sut.Resolve(ctx, oembed.Request{
+			URL: "https://galleria.example/galleria/posts/" + imageID.String(),
+		})
+		if err != oembed.ErrPostNotFound {
+			t.Errorf("expected ErrPostNotFound, got %v", err)
+		}
+	})
+}
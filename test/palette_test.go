@@ -0,0 +1,165 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/models"
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/palette"
+	"github.com/edulustosa/galleria/internal/storage"
+)
+
+func TestPalette(t *testing.T) {
+	t.Run("the most covered color should come first", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 30, 30))
+		for y := 0; y < 30; y++ {
+			for x := 0; x < 30; x++ {
+				c := color.RGBA{R: 220, G: 20, B: 30, A: 255}
+				if x >= 20 {
+					c = color.RGBA{R: 20, G: 40, B: 200, A: 255}
+				}
+				img.Set(x, y, c)
+			}
+		}
+
+		colors := palette.Extract(img)
+		if len(colors) != 2 || colors[0].Hex != "#dc141e" || colors[1].Hex != "#1428c8" {
+			t.Errorf("unexpected palette: %v", colors)
+		}
+	})
+
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	palettesRepository := repo.NewPGXPalettesRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+
+	ctx := context.Background()
+
+	t.Run("users should be able to browse by a close color", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		pending, err := palettesRepository.FindPending(ctx, 10)
+		if err != nil || len(pending) != 1 {
+			t.Fatalf("expected the image to be pending, got %v %v", pending, err)
+		}
+
+		red, _ := palette.ParseHex("dc141e")
+		err = palettesRepository.SetPalette(ctx, pending[0], []repo.PaletteColor{
+			{Hex: "#dc141e", Lab: red, Weight: 1},
+		}, 1200, 800)
+		if err != nil {
+			t.Fatalf("failed to set palette: %v", err)
+		}
+
+		image, err := sut.GetImage(ctx, userID, imageID)
+		if err != nil || len(image.Palette) != 1 {
+			t.Fatalf("unexpected image: %v %v", image, err)
+		}
+
+		if image.Width == nil || *image.Width != 1200 || image.Height == nil || *image.Height != 800 {
+			t.Errorf("unexpected dimensions: %v %v", image.Width, image.Height)
+		}
+
+		posts, _, err := sut.Browse(ctx, galleria.BrowseOptions{Color: "e0102a"})
+		if err != nil {
+			t.Fatalf("failed to browse: %v", err)
+		}
+
+		if len(posts) != 1 {
+			t.Errorf("expected the image to match a close red, got %v", posts)
+		}
+
+		posts, _, err = sut.Browse(ctx, galleria.BrowseOptions{Color: "00ff00"})
+		if err != nil {
+			t.Fatalf("failed to browse: %v", err)
+		}
+
+		if len(posts) != 0 {
+			t.Errorf("expected the image not to match green, got %v", posts)
+		}
+	})
+
+	t.Run("the worker should read stored images from disk", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+		for y := 0; y < 20; y++ {
+			for x := 0; x < 40; x++ {
+				img.Set(x, y, color.RGBA{R: 220, G: 20, B: 30, A: 255})
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatalf("failed to encode image: %v", err)
+		}
+
+		// localhost is refused by the public client, the image can only be
+		// read from disk.
+		files := storage.New(t.TempDir(), "http://localhost:8080")
+		key := storage.ImagesDir + "/red.png"
+		if err := files.Put(key, &buf); err != nil {
+			t.Fatalf("failed to store image: %v", err)
+		}
+
+		imageID, err := imagesRepository.Create(ctx, &models.Image{
+			UserID: userID,
+			Title:  "image title",
+			URL:    files.URL(key),
+		})
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		worker := palette.NewWorker(palettesRepository, files)
+		if _, err := worker.ProcessPending(ctx); err != nil {
+			t.Fatalf("failed to process palettes: %v", err)
+		}
+
+		image, err := sut.GetImage(ctx, userID, imageID)
+		if err != nil {
+			t.Fatalf("failed to get image: %v", err)
+		}
+
+		if len(image.Palette) != 1 || image.Palette[0] != "#dc141e" {
+			t.Errorf("unexpected palette: %v", image.Palette)
+		}
+
+		if image.Width == nil || *image.Width != 40 || image.Height == nil || *image.Height != 20 {
+			t.Errorf("unexpected dimensions: %v %v", image.Width, image.Height)
+		}
+	})
+}
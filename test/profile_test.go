@@ -7,6 +7,7 @@ import (
 	"github.com/edulustosa/galleria/internal/database/models"
 	"github.com/edulustosa/galleria/internal/database/repo"
 	"github.com/edulustosa/galleria/internal/profile"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -18,7 +19,9 @@ func TestAuth_Profile(t *testing.T) {
 
 	usersRepository := repo.NewPGXUsersRepository(dbpool)
 	imagesRepository := repo.NewPGXImagesRepository(dbpool)
-	profileService := profile.New(usersRepository, imagesRepository)
+	followsRepository := repo.NewPGXFollowsRepository(dbpool)
+	blocksRepository := repo.NewPGXBlocksRepository(dbpool)
+	profileService := profile.New(usersRepository, imagesRepository, followsRepository, blocksRepository)
 
 	t.Run("user should be able to update profile", func(t *testing.T) {
 		if err = TruncateTables(dbpool); err != nil {
@@ -79,4 +82,29 @@ func TestAuth_Profile(t *testing.T) {
 		user, _ := usersRepository.FindByID(context.Background(), userID)
 		PrettyPrint(user)
 	})
+
+	t.Run("public profiles should be found by username in any case", func(t *testing.T) {
+		if err = TruncateTables(dbpool); err != nil {
+			t.Fatal("Failed to truncate tables", err.Error())
+		}
+
+		userID, err := SignUpNamedUser(usersRepository, "Jane")
+		if err != nil {
+			t.Fatal("Failed to sign up user:", err.Error())
+		}
+
+		user, err := profileService.GetPublicProfile(context.Background(), uuid.Nil, "jane")
+		if err != nil {
+			t.Fatal("Failed to get public profile:", err.Error())
+		}
+
+		if user.ID != userID || user.Username != "Jane" {
+			t.Errorf("unexpected profile: %v", user)
+		}
+
+		_, err = profileService.GetPublicProfile(context.Background(), uuid.Nil, "nobody")
+		if err != profile.ErrUserNotFound {
+			t.Errorf("expected ErrUserNotFound, got %v", err)
+		}
+	})
 }
@@ -0,0 +1,154 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/reactions"
+	"github.com/google/uuid"
+)
+
+func TestReactions(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	reactionsRepository := repo.NewPGXReactionsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	galleriaService := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+	sut := reactions.New(imagesRepository, commentsRepository, reactionsRepository, notificationsRepository)
+
+	ctx := context.Background()
+
+	t.Run("users should be able to react to a post once per emoji", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		for _, emoji := range []string{"🔥", "🔥", "❤"} {
+			if err := sut.ReactToImage(ctx, userID, imageID, emoji); err != nil {
+				t.Fatalf("failed to react: %v", err)
+			}
+		}
+
+		posts, err := galleriaService.Display(ctx, 1)
+		if err != nil {
+			t.Fatalf("failed to display images: %v", err)
+		}
+
+		if len(posts) != 1 {
+			t.Fatalf("unexpected number of posts: %v", len(posts))
+		}
+
+		if posts[0].Reactions["🔥"] != 1 || posts[0].Reactions[reactions.Like] != 1 {
+			t.Errorf("unexpected reactions: %v", posts[0].Reactions)
+		}
+
+		PrettyPrint(posts)
+	})
+
+	t.Run("users should not be able to react with an emoji outside the allowlist", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		err = sut.ReactToImage(ctx, userID, imageID, "🍕")
+		if err != reactions.ErrInvalidEmoji {
+			t.Errorf("expected ErrInvalidEmoji, got %v", err)
+		}
+	})
+
+	t.Run("users should be able to remove a reaction from a comment", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		commentID, err := galleriaService.AddComment(ctx, userID, imageID, "nice")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		if err := sut.ReactToComment(ctx, userID, commentID, "👏"); err != nil {
+			t.Fatalf("failed to react: %v", err)
+		}
+
+		if err := sut.UnreactToComment(ctx, userID, commentID, "👏"); err != nil {
+			t.Fatalf("failed to remove reaction: %v", err)
+		}
+
+		comments, err := galleriaService.GetComments(ctx, uuid.Nil, imageID)
+		if err != nil {
+			t.Fatalf("failed to get comments: %v", err)
+		}
+
+		if len(comments) != 1 || len(comments[0].Reactions) != 0 {
+			t.Errorf("unexpected comments: %v", comments)
+		}
+	})
+	t.Run("users should not be able to react to hidden comments", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		commentID, err := galleriaService.AddComment(ctx, userID, imageID, "nice")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE comments SET hidden_at = NOW() WHERE id = $1", commentID)
+		if err != nil {
+			t.Fatalf("failed to hide comment: %v", err)
+		}
+
+		if err := sut.ReactToComment(ctx, userID, commentID, reactions.Like); err != reactions.ErrCommentNotFound {
+			t.Errorf("expected ErrCommentNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+)
+
+func TestRevisions(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+
+	ctx := context.Background()
+
+	t.Run("edits should be recorded and revertable by moderators", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		ownerID, err := SignUpNamedUser(usersRepository, "owner")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		moderatorID, err := SignUpNamedUser(usersRepository, "moderator")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE users SET is_moderator = true WHERE id = $1", moderatorID)
+		if err != nil {
+			t.Fatalf("failed to appoint moderator: %v", err)
+		}
+
+		strangerID, err := SignUpNamedUser(usersRepository, "stranger")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, ownerID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		title := "edited title"
+		err = sut.UpdateImage(ctx, ownerID, imageID, &galleria.UpdateImageRequest{Title: &title})
+		if err != nil {
+			t.Fatalf("failed to update image: %v", err)
+		}
+
+		// Changing untracked fields does not record a revision.
+		visibility := "unlisted"
+		err = sut.UpdateImage(ctx, ownerID, imageID, &galleria.UpdateImageRequest{Visibility: &visibility})
+		if err != nil {
+			t.Fatalf("failed to update image: %v", err)
+		}
+
+		if _, err := sut.Revisions(ctx, strangerID, imageID); err != galleria.ErrNotImageOwner {
+			t.Errorf("expected ErrNotImageOwner, got %v", err)
+		}
+
+		if err := sut.Revert(ctx, moderatorID, imageID, 1); err != nil {
+			t.Fatalf("failed to revert image: %v", err)
+		}
+
+		revisions, err := sut.Revisions(ctx, ownerID, imageID)
+		if err != nil {
+			t.Fatalf("failed to get revisions: %v", err)
+		}
+
+		if len(revisions) != 3 {
+			t.Fatalf("expected 3 revisions, got %v", revisions)
+		}
+
+		latest := revisions[0]
+		if latest.Title != "image title" || latest.RevertedFrom == nil || *latest.RevertedFrom != 1 ||
+			latest.EditorID == nil || *latest.EditorID != moderatorID {
+			t.Errorf("unexpected revision: %v", latest)
+		}
+
+		if err := sut.Revert(ctx, ownerID, imageID, 10); err != galleria.ErrRevisionNotFound {
+			t.Errorf("expected ErrRevisionNotFound, got %v", err)
+		}
+
+		PrettyPrint(revisions)
+	})
+	t.Run("revisions of posts others cannot see should not be found", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		ownerID, err := SignUpNamedUser(usersRepository, "owner")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		strangerID, err := SignUpNamedUser(usersRepository, "stranger")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := sut.SendImage(ctx, ownerID, &galleria.SendImageRequest{
+			Title:  "draft",
+			URL:    "https://example.com/image.jpg",
+			Status: "draft",
+		})
+		if err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		if _, err := sut.Revisions(ctx, strangerID, imageID); err != galleria.ErrImageNotFound {
+			t.Errorf("expected ErrImageNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/google/uuid"
+)
+
+func TestScheduling(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+
+	ctx := context.Background()
+
+	t.Run("drafts should only be seen by their owner until published", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := sut.SendImage(ctx, userID, &galleria.SendImageRequest{
+			Title:  "draft",
+			URL:    "https://example.com/image.jpg",
+			Status: "draft",
+		})
+		if err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		if _, err := sut.GetImage(ctx, uuid.Nil, imageID); err != galleria.ErrImageNotFound {
+			t.Errorf("expected draft not to be found, got %v", err)
+		}
+
+		posts, _, err := sut.Browse(ctx, galleria.BrowseOptions{})
+		if err != nil {
+			t.Fatalf("failed to browse: %v", err)
+		}
+
+		if len(posts) != 0 {
+			t.Fatalf("expected draft to be left out, got %v", posts)
+		}
+
+		status := "published"
+		err = sut.UpdateImage(ctx, userID, imageID, &galleria.UpdateImageRequest{Status: &status})
+		if err != nil {
+			t.Fatalf("failed to publish image: %v", err)
+		}
+
+		posts, _, err = sut.Browse(ctx, galleria.BrowseOptions{})
+		if err != nil {
+			t.Fatalf("failed to browse: %v", err)
+		}
+
+		if len(posts) != 1 || posts[0].Image.ID != imageID {
+			t.Fatalf("expected published post, got %v", posts)
+		}
+
+		status = "draft"
+		err = sut.UpdateImage(ctx, userID, imageID, &galleria.UpdateImageRequest{Status: &status})
+		if err != galleria.ErrAlreadyPublished {
+			t.Errorf("expected ErrAlreadyPublished, got %v", err)
+		}
+	})
+
+	t.Run("scheduled posts should be published once due", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		past := time.Now().Add(-time.Hour)
+		req := galleria.SendImageRequest{
+			Title:     "scheduled",
+			URL:       "https://example.com/image.jpg",
+			Status:    "scheduled",
+			PublishAt: &past,
+		}
+		if problems := req.Valid(); problems["publishAt"] == "" {
+			t.Errorf("expected a publish time in the past to be rejected")
+		}
+
+		later := time.Now().Add(time.Hour)
+		req.PublishAt = &later
+		imageID, err := sut.SendImage(ctx, userID, &req)
+		if err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		published, err := sut.PublishDue(ctx)
+		if err != nil || published != 0 {
+			t.Fatalf("expected nothing to be published, got %d %v", published, err)
+		}
+
+		_, err = pool.Exec(ctx, "UPDATE images SET publish_at = $1 WHERE id = $2", past.UTC(), imageID)
+		if err != nil {
+			t.Fatalf("failed to move publish time: %v", err)
+		}
+
+		published, err = sut.PublishDue(ctx)
+		if err != nil || published != 1 {
+			t.Fatalf("expected the post to be published, got %d %v", published, err)
+		}
+
+		image, err := sut.GetImage(ctx, uuid.Nil, imageID)
+		if err != nil {
+			t.Fatalf("failed to get image: %v", err)
+		}
+
+		if image.Status != "published" {
+			t.Errorf("unexpected status: %s", image.Status)
+		}
+	})
+	t.Run("scheduled posts should be able to be rescheduled", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		later := time.Now().Add(time.Hour)
+		imageID, err := sut.SendImage(ctx, userID, &galleria.SendImageRequest{
+			Title:     "scheduled",
+			URL:       "https://example.com/image.jpg",
+			Status:    "scheduled",
+			PublishAt: &later,
+		})
+		if err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		tomorrow := time.Now().Add(24 * time.Hour)
+		req := galleria.UpdateImageRequest{PublishAt: &tomorrow}
+		if problems := req.Valid(); len(problems) != 0 {
+			t.Fatalf("expected rescheduling to be valid, got %v", problems)
+		}
+
+		if err := sut.UpdateImage(ctx, userID, imageID, &req); err != nil {
+			t.Fatalf("failed to reschedule image: %v", err)
+		}
+
+		image, err := sut.GetImage(ctx, userID, imageID)
+		if err != nil {
+			t.Fatalf("failed to get image: %v", err)
+		}
+
+		if image.PublishAt == nil || !image.PublishAt.Equal(tomorrow.UTC().Truncate(time.Microsecond)) {
+			t.Errorf("unexpected publish time: %v", image.PublishAt)
+		}
+
+		publishedID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		err = sut.UpdateImage(ctx, userID, publishedID, &req)
+		if err != galleria.ErrNotScheduled {
+			t.Errorf("expected ErrNotScheduled, got %v", err)
+		}
+	})
+}
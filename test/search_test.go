@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+)
+
+func TestGalleria_Search(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+
+	ctx := context.Background()
+
+	t.Run("users should be able to find images by what they depict", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		description := "A lonely lighthouse standing against the storm"
+		requests := []*galleria.SendImageRequest{
+			{Title: "Sunset at the beach", URL: "http://image.com"},
+			{Title: "Coastal nights", Description: &description, URL: "http://image.com"},
+		}
+
+		for _, req := range requests {
+			if _, err := sut.SendImage(ctx, userID, req); err != nil {
+				t.Fatalf("failed to send image: %v", err)
+			}
+		}
+
+		results, _, err := sut.Search(ctx, galleria.SearchOptions{Query: "lighthouses"})
+		if err != nil {
+			t.Fatalf("failed to search: %v", err)
+		}
+
+		if len(results) != 1 || results[0].Image.Title != "Coastal nights" {
+			t.Fatalf("unexpected results: %v", results)
+		}
+
+		highlight := results[0].Highlights.Description
+		if highlight == nil || !strings.Contains(*highlight, "<mark>lighthouse</mark>") {
+			t.Errorf("expected the match to be highlighted, got %v", highlight)
+		}
+
+		PrettyPrint(results)
+	})
+	t.Run("posts in other languages should not be left out", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		req := &galleria.SendImageRequest{Title: "Farol", URL: "http://image.com", Language: "simple"}
+		if _, err := sut.SendImage(ctx, userID, req); err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		results, _, err := sut.Search(ctx, galleria.SearchOptions{Query: "farol"})
+		if err != nil {
+			t.Fatalf("failed to search: %v", err)
+		}
+
+		if len(results) != 1 {
+			t.Errorf("expected the post to be found, got %v", results)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/stream"
+)
+
+func TestStream(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := stream.NewBroker(pool)
+	go broker.Run(ctx)
+
+	t.Run("new comments should be published to the post topic", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		messages, unsubscribe := broker.Subscribe(stream.PostTopic(imageID))
+		defer unsubscribe()
+
+		// Give the broker time to start listening.
+		time.Sleep(500 * time.Millisecond)
+
+		commentID, err := sut.AddComment(ctx, userID, imageID, "live!")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		select {
+		case msg := <-messages:
+			if msg.ID != commentID {
+				t.Errorf("expected comment %s, got %v", commentID, msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the comment")
+		}
+	})
+
+	t.Run("reconnecting clients should get the comments they missed", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		seenID, err := sut.AddComment(ctx, userID, imageID, "seen")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		missedID, err := sut.AddComment(ctx, userID, imageID, "missed")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		seen, err := sut.GetComment(ctx, userID, seenID)
+		if err != nil {
+			t.Fatalf("failed to get comment: %v", err)
+		}
+
+		missed, err := sut.CommentsAfter(ctx, userID, imageID, galleria.CommentEventID(seen))
+		if err != nil {
+			t.Fatalf("failed to replay comments: %v", err)
+		}
+
+		if len(missed) != 1 || missed[0].ID != missedID {
+			t.Errorf("expected only the missed comment, got %v", missed)
+		}
+	})
+
+	t.Run("comments of muted users should not be streamed", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		viewerID, err := SignUpNamedUser(usersRepository, "viewer")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		mutedID, err := SignUpNamedUser(usersRepository, "muted")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, viewerID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		seenID, err := sut.AddComment(ctx, viewerID, imageID, "seen")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		mutedCommentID, err := sut.AddComment(ctx, mutedID, imageID, "muted")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		if err := blocksRepository.Mute(ctx, viewerID, mutedID); err != nil {
+			t.Fatalf("failed to mute user: %v", err)
+		}
+
+		if _, err := sut.GetComment(ctx, viewerID, mutedCommentID); err != galleria.ErrCommentNotFound {
+			t.Errorf("expected ErrCommentNotFound, got %v", err)
+		}
+
+		seen, err := sut.GetComment(ctx, viewerID, seenID)
+		if err != nil {
+			t.Fatalf("failed to get comment: %v", err)
+		}
+
+		missed, err := sut.CommentsAfter(ctx, viewerID, imageID, galleria.CommentEventID(seen))
+		if err != nil {
+			t.Fatalf("failed to replay comments: %v", err)
+		}
+
+		if len(missed) != 0 {
+			t.Errorf("expected the muted comment to be left out, got %v", missed)
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/tags"
+	"github.com/google/uuid"
+)
+
+func TestTags(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	tagsRepository := repo.NewPGXTagsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+	tagsService := tags.New(tagsRepository)
+
+	ctx := context.Background()
+
+	t.Run("images should be tagged with explicit tags and hashtags", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		description := "Golden hour at the pier #Sunset #beach"
+		imageID, err := sut.SendImage(ctx, userID, &galleria.SendImageRequest{
+			Title:       "image title",
+			Description: &description,
+			URL:         "http://image.com",
+			Tags:        []string{"#Travel", "sunset"},
+		})
+		if err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		image, err := imagesRepository.FindByID(ctx, imageID, uuid.Nil)
+		if err != nil {
+			t.Fatalf("failed to find image: %v", err)
+		}
+
+		if !slices.Equal(image.Tags, []string{"beach", "sunset", "travel"}) {
+			t.Errorf("unexpected tags: %v", image.Tags)
+		}
+
+		posts, _, err := sut.Browse(ctx, galleria.BrowseOptions{Tag: "Beach"})
+		if err != nil {
+			t.Fatalf("failed to browse tag: %v", err)
+		}
+
+		if len(posts) != 1 || posts[0].Image.ID != imageID {
+			t.Errorf("unexpected posts: %v", posts)
+		}
+
+		popular, err := tagsService.Popular(ctx, "day")
+		if err != nil {
+			t.Fatalf("failed to get popular tags: %v", err)
+		}
+
+		if len(popular) != 3 {
+			t.Errorf("unexpected popular tags: %v", popular)
+		}
+	})
+
+	t.Run("owners should be able to retag their images", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := sut.SendImage(ctx, userID, &galleria.SendImageRequest{
+			Title: "image title",
+			URL:   "http://image.com",
+			Tags:  []string{"cats"},
+		})
+		if err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		newTags := []string{"dogs"}
+		err = sut.UpdateImage(ctx, userID, imageID, &galleria.UpdateImageRequest{Tags: &newTags})
+		if err != nil {
+			t.Fatalf("failed to update image: %v", err)
+		}
+
+		image, err := imagesRepository.FindByID(ctx, imageID, uuid.Nil)
+		if err != nil {
+			t.Fatalf("failed to find image: %v", err)
+		}
+
+		if !slices.Equal(image.Tags, newTags) {
+			t.Errorf("unexpected tags: %v", image.Tags)
+		}
+	})
+	t.Run("hashtags removed from the description should untag the image", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		description := "at the pier #sunset"
+		imageID, err := sut.SendImage(ctx, userID, &galleria.SendImageRequest{
+			Title:       "image title",
+			Description: &description,
+			URL:         "http://image.com",
+			Tags:        []string{"travel"},
+		})
+		if err != nil {
+			t.Fatalf("failed to send image: %v", err)
+		}
+
+		description = "at the pier"
+		err = sut.UpdateImage(ctx, userID, imageID, &galleria.UpdateImageRequest{Description: &description})
+		if err != nil {
+			t.Fatalf("failed to update image: %v", err)
+		}
+
+		image, err := imagesRepository.FindByID(ctx, imageID, uuid.Nil)
+		if err != nil {
+			t.Fatalf("failed to find image: %v", err)
+		}
+
+		if !slices.Equal(image.Tags, []string{"travel"}) {
+			t.Errorf("unexpected tags: %v", image.Tags)
+		}
+
+		description = "at the pier #sunset"
+		err = sut.UpdateImage(ctx, userID, imageID, &galleria.UpdateImageRequest{Description: &description})
+		if err != nil {
+			t.Fatalf("failed to update image: %v", err)
+		}
+
+		manyTags := make([]string, 0, tags.MaxPerImage)
+		for _, tag := range "abcdefghij"[:tags.MaxPerImage] {
+			manyTags = append(manyTags, string(tag))
+		}
+
+		req := galleria.UpdateImageRequest{Tags: &manyTags}
+		if problems := req.Valid(); len(problems) != 0 {
+			t.Fatalf("expected the tags alone to be valid, got %v", problems)
+		}
+
+		err = sut.UpdateImage(ctx, userID, imageID, &req)
+		if err != galleria.ErrTooManyTags {
+			t.Errorf("expected ErrTooManyTags, got %v", err)
+		}
+	})
+}
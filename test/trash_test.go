@@ -0,0 +1,201 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/edulustosa/galleria/internal/trash"
+	"github.com/google/uuid"
+)
+
+func TestTrash(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	trashRepository := repo.NewPGXTrashRepository(pool)
+	galleriaService := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+	sut := trash.New(trashRepository, imagesRepository, commentsRepository)
+
+	ctx := context.Background()
+
+	t.Run("deleted posts should be restorable from the trash", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		if err := sut.DeleteImage(ctx, userID, imageID); err != nil {
+			t.Fatalf("failed to delete image: %v", err)
+		}
+
+		if _, err := galleriaService.GetImage(ctx, userID, imageID); err != galleria.ErrImageNotFound {
+			t.Errorf("expected deleted image not to be found, got %v", err)
+		}
+
+		items, err := sut.List(ctx, userID)
+		if err != nil {
+			t.Fatalf("failed to list trash: %v", err)
+		}
+
+		if len(items.Posts) != 1 || !items.Posts[0].PurgeAt.After(time.Now()) {
+			t.Fatalf("unexpected trash: %v", items)
+		}
+
+		if err := sut.RestoreImage(ctx, userID, imageID); err != nil {
+			t.Fatalf("failed to restore image: %v", err)
+		}
+
+		if _, err := galleriaService.GetImage(ctx, uuid.Nil, imageID); err != nil {
+			t.Errorf("expected restored image to be found, got %v", err)
+		}
+
+		if err := sut.RestoreImage(ctx, userID, imageID); err != trash.ErrNotInTrash {
+			t.Errorf("expected ErrNotInTrash, got %v", err)
+		}
+	})
+
+	t.Run("only authors should be able to delete their comments", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		ownerID, err := SignUpNamedUser(usersRepository, "owner")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		authorID, err := SignUpNamedUser(usersRepository, "author")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, ownerID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		commentID, err := galleriaService.AddComment(ctx, authorID, imageID, "nice")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		if err := sut.DeleteComment(ctx, ownerID, commentID); err != trash.ErrNotOwner {
+			t.Errorf("expected ErrNotOwner, got %v", err)
+		}
+
+		if err := sut.DeleteComment(ctx, authorID, commentID); err != nil {
+			t.Fatalf("failed to delete comment: %v", err)
+		}
+
+		comments, err := galleriaService.GetComments(ctx, uuid.Nil, imageID)
+		if err != nil {
+			t.Fatalf("failed to get comments: %v", err)
+		}
+
+		if len(comments) != 0 {
+			t.Errorf("expected deleted comment to be left out, got %v", comments)
+		}
+	})
+
+	t.Run("the purge should only delete expired items", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		userID, err := SignUpUser(usersRepository)
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, userID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		if err := sut.DeleteImage(ctx, userID, imageID); err != nil {
+			t.Fatalf("failed to delete image: %v", err)
+		}
+
+		purged, err := trash.NewPurger(trashRepository).Purge(ctx)
+		if err != nil || purged != 0 {
+			t.Fatalf("expected nothing to be purged, got %d %v", purged, err)
+		}
+
+		purged, err = trashRepository.Purge(ctx, time.Now().Add(time.Minute))
+		if err != nil || purged != 1 {
+			t.Fatalf("expected the image to be purged, got %d %v", purged, err)
+		}
+
+		if _, err := imagesRepository.GetImageByID(ctx, imageID); err == nil {
+			t.Errorf("expected purged image to be gone")
+		}
+	})
+
+	t.Run("replies should survive the purge of the comment they answer", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		authorID, err := SignUpNamedUser(usersRepository, "author")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		replierID, err := SignUpNamedUser(usersRepository, "replier")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		imageID, err := CreateImage(imagesRepository, authorID)
+		if err != nil {
+			t.Fatalf("failed to create image: %v", err)
+		}
+
+		commentID, err := galleriaService.AddComment(ctx, authorID, imageID, "first")
+		if err != nil {
+			t.Fatalf("failed to add comment: %v", err)
+		}
+
+		replyID, err := galleriaService.Reply(ctx, replierID, imageID, commentID, "reply")
+		if err != nil {
+			t.Fatalf("failed to reply: %v", err)
+		}
+
+		if err := sut.DeleteComment(ctx, authorID, commentID); err != nil {
+			t.Fatalf("failed to delete comment: %v", err)
+		}
+
+		purged, err := trashRepository.Purge(ctx, time.Now().Add(time.Minute))
+		if err != nil || purged != 1 {
+			t.Fatalf("expected the comment to be purged, got %d %v", purged, err)
+		}
+
+		comments, err := galleriaService.GetComments(ctx, uuid.Nil, imageID)
+		if err != nil {
+			t.Fatalf("failed to get comments: %v", err)
+		}
+
+		if len(comments) != 1 || comments[0].ID != replyID || comments[0].ParentID != nil {
+			t.Errorf("expected the reply to be kept as a top level comment, got %v", comments)
+		}
+	})
+}
@@ -59,3 +59,13 @@ func CreateImage(imagesRepository repo.ImagesRepository, userID uuid.UUID) (uuid
 		URL:    "https://example.com/image.jpg",
 	})
 }
+
+func SignUpNamedUser(usersRepository repo.UsersRepository, username string) (uuid.UUID, error) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("12345678"), bcrypt.DefaultCost)
+
+	return usersRepository.Create(context.Background(), &models.User{
+		Username:     username,
+		Email:        username + "@email.com",
+		PasswordHash: string(hashedPassword),
+	})
+}
@@ -0,0 +1,85 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edulustosa/galleria/internal/database/repo"
+	"github.com/edulustosa/galleria/internal/galleria"
+	"github.com/google/uuid"
+)
+
+func TestVisibility(t *testing.T) {
+	pool, err := LoadDatabase()
+	if err != nil {
+		t.Fatalf("failed to connect with database: %v", err)
+	}
+
+	usersRepository := repo.NewPGXUsersRepository(pool)
+	imagesRepository := repo.NewPGXImagesRepository(pool)
+	commentsRepository := repo.NewPGXCommentsRepository(pool)
+	notificationsRepository := repo.NewPGXNotificationsRepository(pool)
+	blocksRepository := repo.NewPGXBlocksRepository(pool)
+	sut := galleria.New(usersRepository, imagesRepository, commentsRepository, notificationsRepository, blocksRepository)
+
+	ctx := context.Background()
+
+	t.Run("only public posts should be listed", func(t *testing.T) {
+		if err := TruncateTables(pool); err != nil {
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+
+		ownerID, err := SignUpNamedUser(usersRepository, "owner")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		visitorID, err := SignUpNamedUser(usersRepository, "visitor")
+		if err != nil {
+			t.Fatalf("failed to sign up user: %v", err)
+		}
+
+		ids := make(map[string]uuid.UUID)
+		for _, visibility := range galleria.Visibilities {
+			id, err := sut.SendImage(ctx, ownerID, &galleria.SendImageRequest{
+				Title:      visibility,
+				URL:        "https://example.com/image.jpg",
+				Visibility: visibility,
+			})
+			if err != nil {
+				t.Fatalf("failed to send image: %v", err)
+			}
+			ids[visibility] = id
+		}
+
+		posts, _, err := sut.Browse(ctx, galleria.BrowseOptions{ViewerID: visitorID})
+		if err != nil {
+			t.Fatalf("failed to browse: %v", err)
+		}
+
+		if len(posts) != 1 || posts[0].Image.ID != ids["public"] {
+			t.Errorf("unexpected feed: %v", posts)
+		}
+
+		if _, err := sut.GetImage(ctx, visitorID, ids["unlisted"]); err != nil {
+			t.Errorf("expected unlisted post to be reachable by link, got %v", err)
+		}
+
+		if _, err := sut.GetImage(ctx, uuid.Nil, ids["private"]); err != galleria.ErrImageNotFound {
+			t.Errorf("expected ErrImageNotFound, got %v", err)
+		}
+
+		if _, err := sut.AddComment(ctx, visitorID, ids["private"], "hi"); err != galleria.ErrImageNotFound {
+			t.Errorf("expected ErrImageNotFound, got %v", err)
+		}
+
+		posts, _, err = sut.Browse(ctx, galleria.BrowseOptions{Username: "owner", ViewerID: ownerID})
+		if err != nil {
+			t.Fatalf("failed to browse: %v", err)
+		}
+
+		if len(posts) != 3 {
+			t.Errorf("expected the owner to see every post, got %v", posts)
+		}
+	})
+}